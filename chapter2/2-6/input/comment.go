@@ -0,0 +1,57 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Comment reads the // or /* */ comment that starts with the '/' that Read
+// returned last, and returns its text. If no comment starts there it returns
+// false, and the character after the '/' is given back to be read again. The
+// '\n' that ends a // comment is given back too, so that it is read as the
+// blank it is. When nested is true, /* */ comments nest.
+func (r *Reader) Comment(nested bool) (string, bool, error) {
+	begin := r.pos
+	ch, ok := r.Read()
+	if !ok || ch != '/' && ch != '*' {
+		if ok {
+			r.unread()
+		}
+		return "", false, r.err
+	}
+	var text strings.Builder
+	text.WriteByte('/')
+	text.WriteRune(ch)
+	if ch == '/' {
+		for {
+			if ch, ok = r.Read(); !ok {
+				return text.String(), true, r.err
+			}
+			if ch == '\n' {
+				r.unread()
+				return text.String(), true, nil
+			}
+			text.WriteRune(ch)
+		}
+	}
+	depth := 1
+	for prev := rune(0); depth > 0; {
+		if ch, ok = r.Read(); !ok {
+			if r.err != nil {
+				return "", false, r.err
+			}
+			return "", false, fmt.Errorf("Comment(): unterminated comment at %v", begin)
+		}
+		text.WriteRune(ch)
+		if prev == '*' && ch == '/' {
+			depth--
+			prev = 0
+		} else if nested && prev == '/' && ch == '*' {
+			depth++
+			prev = 0
+		} else {
+			prev = ch
+		}
+	}
+	return text.String(), true, nil
+}
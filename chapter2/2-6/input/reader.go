@@ -0,0 +1,109 @@
+// Package input reads the characters of the lexers of chapter 2 and keeps
+// track of where each of them is in the input, so that the tokens made of
+// them can tell where they start and end.
+package input
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+)
+
+// Position is a location in the input. Line and Column start at 1 and Column
+// counts bytes; Offset is the number of bytes before the location.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+	Offset int
+}
+
+func (pos Position) String() string {
+	if pos.File == "" {
+		return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", pos.File, pos.Line, pos.Column)
+}
+
+// Reader reads the characters of a file one at a time.
+type Reader struct {
+	in   *bufio.Reader
+	pos  Position // of the character read last
+	next Position // of the character after it
+	prev Position // of the character before it, for unread
+	err  error
+}
+
+// NewReader returns a Reader of r, whose positions are in the file name.
+func NewReader(name string, r io.Reader) *Reader {
+	begin := Position{File: name, Line: 1, Column: 1}
+	return &Reader{in: bufio.NewReader(r), pos: begin, next: begin}
+}
+
+// Read returns the next character. It returns false at the end of input, or
+// on an error that Err then returns.
+func (r *Reader) Read() (rune, bool) {
+	ch, size, err := r.in.ReadRune()
+	if err != nil {
+		if err != io.EOF {
+			r.err = err
+		}
+		r.pos = r.next
+		return 0, false
+	}
+	r.prev, r.pos = r.pos, r.next
+	r.next.Offset += size
+	if ch == '\n' {
+		r.next.Line++
+		r.next.Column = 1
+	} else {
+		r.next.Column += size
+	}
+	return ch, true
+}
+
+// ReadAhead reads the next character into *peek, the character that a lexer
+// of chapter 2 has read ahead of its tokens, and reports false at the end of
+// input, leaving *peek as it was. Such a lexer cannot go on without its
+// input, so an error reading it ends the program.
+func (r *Reader) ReadAhead(peek *rune) bool {
+	ch, ok := r.Read()
+	if !ok {
+		if r.err != nil {
+			log.Fatalln("ReadAhead():", r.err)
+		}
+		return false
+	}
+	*peek = ch
+	return true
+}
+
+// IsDigit reports whether ch is an ASCII digit; Unicode letters and digits
+// are accepted in identifiers, but numbers are written only with 0-9.
+func IsDigit(ch rune) bool {
+	return '0' <= ch && ch <= '9'
+}
+
+// unread gives back the character that Read returned last, which must not
+// have been given back already, so that Read returns it again.
+func (r *Reader) unread() {
+	r.in.UnreadRune()
+	r.next, r.pos = r.pos, r.prev
+}
+
+// Pos returns the position of the character that Read returned last, or that
+// of the end of input once Read has returned false.
+func (r *Reader) Pos() Position {
+	return r.pos
+}
+
+// End returns the position just past the character that Read returned last.
+func (r *Reader) End() Position {
+	return r.next
+}
+
+// Err returns the error that ended the input, if it was not io.EOF.
+func (r *Reader) Err() error {
+	return r.err
+}
@@ -0,0 +1,119 @@
+package input
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// read is a character that Read returned, with the positions of the Reader
+// after it.
+type read struct {
+	ch       rune
+	pos, end string
+}
+
+// expect checks that r reads want, comparing the positions as at formats
+// them.
+func expect(t *testing.T, r *Reader, want []read) {
+	t.Helper()
+	for _, w := range want {
+		ch, ok := r.Read()
+		got := read{ch, at(r.Pos()), at(r.End())}
+		if w.ch == 0 && ok || w.ch != 0 && !ok || got != w {
+			t.Fatalf("Read() = %q, %v at %s-%s, want %q at %s-%s", got.ch, ok, got.pos, got.end, w.ch, w.pos, w.end)
+		}
+	}
+}
+
+// at formats pos as Line:Column@Offset.
+func at(pos Position) string {
+	return fmt.Sprintf("%v@%d", pos, pos.Offset)
+}
+
+func TestRead(t *testing.T) {
+	r := NewReader("", strings.NewReader("a\tb\né\n\nc"))
+	expect(t, r, []read{
+		{'a', "1:1@0", "1:2@1"},
+		{'\t', "1:2@1", "1:3@2"}, // a tab is one column, as any byte
+		{'b', "1:3@2", "1:4@3"},
+		{'\n', "1:4@3", "2:1@4"},
+		{'é', "2:1@4", "2:3@6"}, // two bytes
+		{'\n', "2:3@6", "3:1@7"},
+		{'\n', "3:1@7", "4:1@8"},
+		{'c', "4:1@8", "4:2@9"},
+		{0, "4:2@9", "4:2@9"}, // the end of input
+		{0, "4:2@9", "4:2@9"},
+	})
+	if err := r.Err(); err != nil {
+		t.Errorf("Err() = %v at the end of input", err)
+	}
+
+	r = NewReader("in.src", strings.NewReader("x"))
+	expect(t, r, []read{{'x', "in.src:1:1@0", "in.src:1:2@1"}})
+}
+
+// TestUnread checks the positions after a character is given back at the
+// start and at the end of a line.
+func TestUnread(t *testing.T) {
+	r := NewReader("", strings.NewReader("a\nb"))
+	expect(t, r, []read{{'a', "1:1@0", "1:2@1"}, {'\n', "1:2@1", "2:1@2"}, {'b', "2:1@2", "2:2@3"}})
+	r.unread()
+	if pos, end := at(r.Pos()), at(r.End()); pos != "1:2@1" || end != "2:1@2" {
+		t.Errorf("after unread of 'b', Pos() = %s, End() = %s, want 1:2@1 and 2:1@2", pos, end)
+	}
+	expect(t, r, []read{{'b', "2:1@2", "2:2@3"}, {0, "2:2@3", "2:2@3"}})
+
+	r = NewReader("", strings.NewReader("a\nb"))
+	expect(t, r, []read{{'a', "1:1@0", "1:2@1"}, {'\n', "1:2@1", "2:1@2"}})
+	r.unread()
+	if pos, end := at(r.Pos()), at(r.End()); pos != "1:1@0" || end != "1:2@1" {
+		t.Errorf("after unread of '\\n', Pos() = %s, End() = %s, want 1:1@0 and 1:2@1", pos, end)
+	}
+	expect(t, r, []read{{'\n', "1:2@1", "2:1@2"}, {'b', "2:1@2", "2:2@3"}})
+}
+
+// TestComment checks that the '\n' after a // comment is given back with its
+// position, and that a '/' that starts no comment gives back what follows.
+func TestComment(t *testing.T) {
+	r := NewReader("", strings.NewReader("// c\n/*\n*/ /x"))
+	expect(t, r, []read{{'/', "1:1@0", "1:2@1"}})
+	if text, ok, err := r.Comment(false); text != "// c" || !ok || err != nil {
+		t.Errorf("Comment() = %q, %v, %v, want // c", text, ok, err)
+	}
+	expect(t, r, []read{{'\n', "1:5@4", "2:1@5"}, {'/', "2:1@5", "2:2@6"}})
+	if text, ok, err := r.Comment(false); text != "/*\n*/" || !ok || err != nil {
+		t.Errorf("Comment() = %q, %v, %v, want /*\\n*/", text, ok, err)
+	}
+	expect(t, r, []read{{' ', "3:3@10", "3:4@11"}, {'/', "3:4@11", "3:5@12"}})
+	if text, ok, err := r.Comment(false); ok || err != nil {
+		t.Errorf("Comment() = %q, %v, %v before x, want no comment", text, ok, err)
+	}
+	expect(t, r, []read{{'x', "3:5@12", "3:6@13"}})
+}
+
+func TestReadAhead(t *testing.T) {
+	r := NewReader("", strings.NewReader("ab"))
+	peek := ' '
+	for _, want := range "ab" {
+		if !r.ReadAhead(&peek) || peek != want {
+			t.Fatalf("ReadAhead() read %q, want %q", peek, want)
+		}
+	}
+	if r.ReadAhead(&peek) || peek != 'b' {
+		t.Errorf("ReadAhead() at the end of input read %q, want 'b' kept", peek)
+	}
+}
+
+func TestErr(t *testing.T) {
+	failure := errors.New("disk on fire")
+	r := NewReader("", iotest.ErrReader(failure))
+	if ch, ok := r.Read(); ok {
+		t.Errorf("Read() = %q, true from a failing reader", ch)
+	}
+	if err := r.Err(); err != failure {
+		t.Errorf("Err() = %v, want %v", err, failure)
+	}
+}
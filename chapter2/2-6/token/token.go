@@ -0,0 +1,108 @@
+// Package token holds the tokens that the lexers of chapter 2 return, and
+// the keyword tables from which they learn their reserved words.
+package token
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter2/2-6/input"
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter2/2-7/intern"
+)
+
+// Tag tells what kind of token a token is. A single character that is a
+// token of its own, such as '+' or ';', is its own tag.
+type Tag int
+
+const (
+	NUM     Tag = 256
+	ID      Tag = 257
+	TRUE    Tag = 258
+	FALSE   Tag = 259
+	TYPE    Tag = 260
+	COMMENT Tag = 261
+)
+
+type Token struct {
+	TAG Tag
+	Pos input.Position // where the token starts
+	End input.Position // just past its last character
+}
+
+type Num struct {
+	TAG   Tag
+	Value int
+	Pos   input.Position // where the token starts
+	End   input.Position // just past its last character
+}
+
+type Word struct {
+	TAG    Tag
+	Lexeme string
+	Name   intern.ID      // the lexeme in the Pool of the Lexer
+	Pos    input.Position // where the token starts
+	End    input.Position // just past its last character
+}
+
+type Comment struct {
+	TAG  Tag
+	Text string
+	Pos  input.Position // where the token starts
+	End  input.Position // just past its last character
+}
+
+// Same reports whether the tokens a and b have the same tag and value or
+// lexeme, wherever they were read.
+func Same(a, b interface{}) bool {
+	switch x := a.(type) {
+	case Token:
+		y, ok := b.(Token)
+		return ok && x.TAG == y.TAG
+	case Num:
+		y, ok := b.(Num)
+		return ok && x.TAG == y.TAG && x.Value == y.Value
+	case Word:
+		y, ok := b.(Word)
+		return ok && x.TAG == y.TAG && x.Lexeme == y.Lexeme
+	}
+	return false
+}
+
+// tagNames are the tags that a keyword table may name instead of giving
+// their numbers.
+var tagNames = map[string]Tag{
+	"TRUE":  TRUE,
+	"FALSE": FALSE,
+	"TYPE":  TYPE,
+}
+
+// LoadKeywords reads a keyword table, one keyword a line followed by its tag,
+// a number or the name of a tag, as in "int TYPE". Blank lines and lines
+// starting with // are ignored.
+func LoadKeywords(r io.Reader) (map[string]Tag, error) {
+	keywords := map[string]Tag{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "//") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("LoadKeywords(): line %d: want a keyword and its tag, have %q", line, text)
+		}
+		tag, ok := tagNames[fields[1]]
+		if !ok {
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("LoadKeywords(): line %d: invalid tag %q", line, fields[1])
+			}
+			tag = Tag(n)
+		}
+		keywords[fields[0]] = tag
+	}
+	return keywords, scanner.Err()
+}
@@ -0,0 +1,60 @@
+package token
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter2/2-6/input"
+)
+
+func TestSame(t *testing.T) {
+	at := input.Position{Line: 2, Column: 5, Offset: 9}
+	tests := []struct {
+		a, b interface{}
+		same bool
+	}{
+		{Token{TAG: '+'}, Token{TAG: '+', Pos: at}, true},
+		{Token{TAG: '+'}, Token{TAG: '-'}, false},
+		{Num{TAG: NUM, Value: 1}, Num{TAG: NUM, Value: 1, End: at}, true},
+		{Num{TAG: NUM, Value: 1}, Num{TAG: NUM, Value: 2}, false},
+		{Word{TAG: ID, Lexeme: "x"}, Word{TAG: ID, Lexeme: "x", Name: 3}, true},
+		{Word{TAG: ID, Lexeme: "x"}, Word{TAG: ID, Lexeme: "y"}, false},
+		{Word{TAG: TRUE, Lexeme: "true"}, Word{TAG: ID, Lexeme: "true"}, false},
+		{Token{TAG: NUM}, Num{TAG: NUM}, false},
+		{Comment{TAG: COMMENT}, Comment{TAG: COMMENT}, false},
+		{nil, nil, false},
+		{Token{TAG: ';'}, nil, false},
+	}
+	for _, test := range tests {
+		if same := Same(test.a, test.b); same != test.same {
+			t.Errorf("Same(%v, %v) = %v, want %v", test.a, test.b, same, test.same)
+		}
+	}
+}
+
+func TestLoadKeywords(t *testing.T) {
+	tests := []struct {
+		table string
+		want  map[string]Tag
+		err   string
+	}{
+		{"// C types\nint TYPE\n\n  yes TRUE  \nno 259\nbegin 300\n", map[string]Tag{"int": TYPE, "yes": TRUE, "no": FALSE, "begin": 300}, ""},
+		{"", map[string]Tag{}, ""},
+		{"int\n", nil, `LoadKeywords(): line 1: want a keyword and its tag, have "int"`},
+		{"int TYPE\nbool TYPE BOOL\n", nil, `LoadKeywords(): line 2: want a keyword and its tag, have "bool TYPE BOOL"`},
+		{"\nbegin BEGIN\n", nil, `LoadKeywords(): line 2: invalid tag "BEGIN"`},
+	}
+	for _, test := range tests {
+		got, err := LoadKeywords(strings.NewReader(test.table))
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("LoadKeywords(%q) error = %v, want %s", test.table, err, test.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("LoadKeywords(%q) = %v, %v, want %v", test.table, got, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Package intern gives every distinct lexeme a small integer, so that a lexer
+// and the symbol tables of section 2.7 can store, hash and compare names as
+// integers and keep a single copy of each spelling.
+package intern
+
+import "strings"
+
+// ID stands for a string interned in a Pool. IDs are numbered from 1 in the
+// order their strings were interned; 0 stands for no string.
+type ID int32
+
+// Pool holds interned strings. It is not safe for concurrent use.
+type Pool struct {
+	ids     map[string]ID
+	strings []string // strings[id] is the string of id
+}
+
+func NewPool() *Pool {
+	return &Pool{ids: map[string]ID{}, strings: []string{""}}
+}
+
+// Intern returns the ID of s, interning a copy of it if it is new, so that s
+// may be a slice of a larger input that the Pool should not keep alive.
+func (p *Pool) Intern(s string) ID {
+	if id, ok := p.ids[s]; ok {
+		return id
+	}
+	s = strings.Clone(s)
+	id := ID(len(p.strings))
+	p.ids[s] = id
+	p.strings = append(p.strings, s)
+	return id
+}
+
+// InternBytes is Intern for a lexeme held in a byte slice; it allocates only
+// for a lexeme that is new.
+func (p *Pool) InternBytes(b []byte) ID {
+	if id, ok := p.ids[string(b)]; ok {
+		return id
+	}
+	return p.Intern(string(b))
+}
+
+// Lookup returns the ID of s, if s has been interned.
+func (p *Pool) Lookup(s string) (ID, bool) {
+	id, ok := p.ids[s]
+	return id, ok
+}
+
+// String returns the string of id.
+func (p *Pool) String(id ID) string {
+	return p.strings[id]
+}
+
+// Len returns the number of strings interned.
+func (p *Pool) Len() int {
+	return len(p.strings) - 1
+}
@@ -0,0 +1,35 @@
+package intern
+
+import "testing"
+
+func TestIntern(t *testing.T) {
+	p := NewPool()
+	input := "count = count + total"
+	count, total := p.Intern(input[:5]), p.Intern(input[16:])
+	if count != 1 || total != 2 {
+		t.Errorf("Intern() = %d, %d, want IDs 1 and 2 in order", count, total)
+	}
+	if id := p.Intern(input[8:13]); id != count {
+		t.Errorf("Intern(%q) = %d, want the ID %d of the equal string", input[8:13], id, count)
+	}
+	b := []byte("total")
+	if id := p.InternBytes(b); id != total {
+		t.Errorf("InternBytes(%q) = %d, want %d", b, id, total)
+	}
+	b[0] = 'T'
+	if id := p.InternBytes(b); id != 3 || p.String(id) != "Total" || p.String(total) != "total" {
+		t.Errorf("InternBytes(%q) = %d, %q; String(%d) = %q", b, id, p.String(id), total, p.String(total))
+	}
+	if p.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", p.Len())
+	}
+	if id, ok := p.Lookup("count"); !ok || id != count {
+		t.Errorf("Lookup(count) = %d, %v, want %d", id, ok, count)
+	}
+	if id, ok := p.Lookup("sum"); ok {
+		t.Errorf("Lookup(sum) = %d, true for a string never interned", id)
+	}
+	if p.Len() != 3 {
+		t.Errorf("Len() = %d after Lookup, want 3", p.Len())
+	}
+}
@@ -2,22 +2,28 @@ package main
 import (
 	"log"
 	"fmt"
-	"io"
 	"unicode"
 	"bytes"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter2/2-6/input"
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter2/2-6/token"
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter2/2-7/intern"
 )
 
 /****************************Env*******************************/
 type Env struct {
-	table map[string]*Symbol
+	table map[intern.ID]*Symbol // keyed by the names of the Lexer's Pool
 	pre *Env
 }
 
 func NewEnv(pre *Env) *Env {
-	return &Env{table:map[string]*Symbol{}, pre:pre}
+	return &Env{table:map[intern.ID]*Symbol{}, pre:pre}
 }
 
-func (env *Env) get(key string) *Symbol {
+func (env *Env) get(key intern.ID) *Symbol {
 	for scope := env; scope != nil; scope = scope.pre {
 		if symbol, ok := scope.table[key]; ok {
 			return symbol
@@ -26,8 +32,8 @@ func (env *Env) get(key string) *Symbol {
 	return nil
 }
 
-func (env *Env) put(key string, symbol *Symbol) {
-	if key == "" || symbol == nil {
+func (env *Env) put(key intern.ID, symbol *Symbol) {
+	if key == 0 || symbol == nil {
 		log.Fatalln("Env::put()", "key==", key, ",symbol==", symbol)
 	}
 	env.table[key] = symbol
@@ -46,8 +52,8 @@ type Parser struct {
 	lexer *Lexer
 }
 
-func NewParser() *Parser {
-	parser := &Parser{lexer:NewLexer()}
+func NewParser(lexer *Lexer) *Parser {
+	parser := &Parser{lexer:lexer}
 	parser.lookahead = parser.lexer.Scan()
 	if parser.lookahead == nil {
 		log.Fatalln("NewParser(): no valid input, parser.lookahead == nil")
@@ -91,7 +97,7 @@ func (parser *Parser) decl() {
 
 	s := NewSymbol()
 	s.Type = typ.Lexeme
-	top.put(id.Lexeme, s)
+	top.put(id.Name, s)
 	//	fmt.Println("top put:", top, top.pre, id.Lexeme, s)
 }
 
@@ -139,7 +145,7 @@ func (parser *Parser) stmtsRest() {
 func (parser *Parser) factor() {
 	id := parser.lookahead.(Word)
 	parser.match(id)
-	s := top.get(id.Lexeme)
+	s := top.get(id.Name)
 	if s == nil {
 		log.Fatal("factor():", "top.get(\"", id.Lexeme, "\") returned nil. top == ", top, "\n")
 	}
@@ -147,7 +153,7 @@ func (parser *Parser) factor() {
 }
 
 func (parser *Parser) match(c interface{}) {
-	if parser.lookahead == c {
+	if token.Same(parser.lookahead, c) {
 		//		{
 		//			if t, ok := c.(Token); ok {
 		//				fmt.Printf("\n<%c> matched\n", t.TAG)
@@ -172,140 +178,184 @@ func (parser *Parser) match(c interface{}) {
 	}
 }
 /*********************************Lexer*************************/
-type Tag int
+// The tokens are those that package token defines for all the lexers of
+// chapter 2.
+type (
+	Tag     = token.Tag
+	Token   = token.Token
+	Num     = token.Num
+	Word    = token.Word
+	Comment = token.Comment
+)
 
 const (
-	NUM Tag = 256
-	ID Tag = 257
-	TRUE Tag = 258
-	FALSE Tag = 259
-	TYPE Tag = 260
+	NUM     = token.NUM
+	ID      = token.ID
+	TRUE    = token.TRUE
+	FALSE   = token.FALSE
+	TYPE    = token.TYPE
+	COMMENT = token.COMMENT
 )
 
-type Token struct {
-	TAG Tag
-}
-
 func NewToken(tag Tag) Token {
-	return Token{tag}
-}
-
-type Num struct {
-	TAG Tag
-	Value int
+	return Token{TAG:tag}
 }
 
 func NewNum(value int) Num {
-	return Num{NUM, value}
-}
-
-type Word struct {
-	TAG Tag
-	Lexeme string
+	return Num{TAG:NUM, Value:value}
 }
 
 func NewWord(tag Tag, lexeme string) Word {
-	return Word{tag, lexeme}
+	return Word{TAG:tag, Lexeme:lexeme}
 }
 
 
 type Lexer struct {
-	Words map[string]interface{}
-	line int
-	peek byte
+	Words map[intern.ID]interface{}
+	Pool *intern.Pool // the identifiers and keywords read so far
+	in *input.Reader // the standard input
+	peek rune
+	EmitComments bool   // return comments as Comment instead of skipping them
+	NestedComments bool // let /* */ comments nest
+	IgnoreCase bool     // match keywords whatever the case of their letters
+	folded map[string]Word // the keywords in lower case
+}
+
+// DefaultKeywords are the reserved words and their tags.
+var DefaultKeywords = map[string]Tag{
+	"true":   TRUE,
+	"false":  FALSE,
+	"int":    TYPE,
+	"char":   TYPE,
+	"bool":   TYPE,
+	"double": TYPE,
+	"float":  TYPE,
 }
 
 func NewLexer() *Lexer {
-	return &Lexer{
-		Words:map[string]interface{}{
-			"true": NewWord(TRUE, "true"),
-			"false": NewWord(FALSE, "false"),
-			"int": NewWord(TYPE, "int"),
-			"char": NewWord(TYPE, "char"),
-			"bool": NewWord(TYPE, "bool"),
-			"double": NewWord(TYPE, "double"),
-			"float": NewWord(TYPE, "float"),
-		},
-		line:0,
+	return NewLexerWords(DefaultKeywords)
+}
+
+// NewLexerWords returns a Lexer that reads the identifiers in keywords as
+// words with their tags.
+func NewLexerWords(keywords map[string]Tag) *Lexer {
+	lexer := &Lexer{
+		Words:map[intern.ID]interface{}{},
+		Pool:intern.NewPool(),
+		folded:map[string]Word{},
+		in:input.NewReader("", os.Stdin),
 		peek:' ',
 	}
+	for lexeme, tag := range keywords {
+		word := NewWord(tag, lexeme)
+		word.Name = lexer.Pool.Intern(lexeme)
+		lexer.Words[word.Name] = word
+		lexer.folded[strings.ToLower(lexeme)] = word
+	}
+	return lexer
 }
 
 func (lexer *Lexer) Scan() interface{} {
 	for {
 		// omit the blank symbol
-		if lexer.peek == ' ' || lexer.peek == '\t' {
-			_, err := fmt.Scanf("%c", &lexer.peek)
-			if err == io.EOF {
+		if lexer.peek == ' ' || lexer.peek == '\t' || lexer.peek == '\n' {
+			if !lexer.in.ReadAhead(&lexer.peek) {
 				return nil
 			}
+			continue
+		}
+		pos, end := lexer.in.Pos(), lexer.in.End()
+
+		// process comments
+		if lexer.peek == '/' {
+			text, ok, err := lexer.in.Comment(lexer.NestedComments)
 			if err != nil {
 				log.Fatalln("Scan():", err)
 			}
-			continue
-		} else if lexer.peek == '\n' {
-			lexer.line++
-			_, err := fmt.Scanf("%c", &lexer.peek)
-			if err == io.EOF {
-				return nil
+			lexer.peek = ' '
+			if !ok {
+				return Token{TAG:'/', Pos:pos, End:end}
 			}
-			if err != nil {
-				log.Fatalln("Scan():", err)
+			if lexer.EmitComments {
+				return Comment{TAG:COMMENT, Text:text, Pos:pos, End:lexer.in.End()}
 			}
 			continue
 		}
 
 		// process digits
-		if unicode.IsDigit(rune(lexer.peek)) {
+		if input.IsDigit(lexer.peek) {
 			v := 0
-			for unicode.IsDigit(rune(lexer.peek)) {
+			for input.IsDigit(lexer.peek) {
 				v = v * 10 + int(lexer.peek - '0')
-				_, err := fmt.Scanf("%c", &lexer.peek)
-				if err == io.EOF {
+				if !lexer.in.ReadAhead(&lexer.peek) {
+					lexer.peek = ' '
 					break
 				}
-				if err != nil {
-					log.Fatalln("Scan() process digits:", err)
-				}
 			}
-			return NewNum(v)
+			num := NewNum(v)
+			num.Pos, num.End = pos, lexer.in.Pos()
+			return num
 		}
 
 		// process identifier
 		var w bytes.Buffer
-		if unicode.IsLetter(rune(lexer.peek)) {
-			w.WriteByte(lexer.peek)
+		if unicode.IsLetter(lexer.peek) {
+			w.WriteRune(lexer.peek)
 			for {
-				_, err := fmt.Scanf("%c", &lexer.peek)
-				if err == io.EOF {
+				if !lexer.in.ReadAhead(&lexer.peek) {
+					lexer.peek = ' '
 					break
 				}
-				if err != nil {
-					log.Fatalln("Scan() process identifier: ", err)
-				}
-				if unicode.IsDigit(rune(lexer.peek)) || unicode.IsLetter(rune(lexer.peek)) {
-					w.WriteByte(lexer.peek)
+				if unicode.IsDigit(lexer.peek) || unicode.IsLetter(lexer.peek) {
+					w.WriteRune(lexer.peek)
 				} else {
 					break
 				}
 			}
-			if word, ok := lexer.Words[w.String()]; ok {
+			end = lexer.in.Pos()
+			name := lexer.Pool.InternBytes(w.Bytes())
+			if word, ok := lexer.Words[name]; ok {
+				tok := word.(Word)
+				tok.Pos, tok.End = pos, end
+				return tok
+			}
+			if word, ok := lexer.folded[strings.ToLower(w.String())]; lexer.IgnoreCase && ok {
+				word.Pos, word.End = pos, end
 				return word
 			}
-			word := NewWord(ID, w.String())
-			lexer.Words[w.String()] = word
+			word := NewWord(ID, lexer.Pool.String(name))
+			word.Name = name
+			lexer.Words[name] = word
+			word.Pos, word.End = pos, end
 			return word
 		}
 
 		// process other symbols
 		//		fmt.Printf("\nscaning... <%c>\n", lexer.peek)
-		tok := NewToken(Tag(lexer.peek))
+		tok := Token{TAG:Tag(lexer.peek), Pos:pos, End:end}
 		lexer.peek = ' '
 		return tok
 	}
 }
 
 func main() {
-	parser := NewParser()
+	keywordFile := flag.String("keywords", "", "read the keywords from a keyword table")
+	ignoreCase := flag.Bool("ignorecase", false, "match keywords whatever the case of their letters")
+	flag.Parse()
+	keywords := DefaultKeywords
+	if *keywordFile != "" {
+		file, err := os.Open(*keywordFile)
+		if err != nil {
+			log.Fatalln("main():", err)
+		}
+		keywords, err = token.LoadKeywords(file)
+		file.Close()
+		if err != nil {
+			log.Fatalln("main():", err)
+		}
+	}
+	lexer := NewLexerWords(keywords)
+	lexer.IgnoreCase = *ignoreCase
+	parser := NewParser(lexer)
 	parser.program()
 }
\ No newline at end of file
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter2/2-6/input"
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter2/2-7/intern"
+)
+
+// TestLexerPool checks that the keywords are in the Pool of a new Lexer, and
+// that every identifier it reads gets the ID of its equal strings.
+func TestLexerPool(t *testing.T) {
+	lexer := NewLexer()
+	if lexer.Pool.Len() != len(DefaultKeywords) {
+		t.Errorf("Pool.Len() = %d, want the %d keywords", lexer.Pool.Len(), len(DefaultKeywords))
+	}
+	for lexeme, tag := range DefaultKeywords {
+		id, ok := lexer.Pool.Lookup(lexeme)
+		if !ok {
+			t.Errorf("keyword %s is not in the Pool", lexeme)
+			continue
+		}
+		if word, ok := lexer.Words[id].(Word); !ok || word.TAG != tag || word.Lexeme != lexeme || word.Name != id {
+			t.Errorf("Words[%d] = %v, want the Word of keyword %s", id, lexer.Words[id], lexeme)
+		}
+	}
+
+	lexer.in = input.NewReader("", strings.NewReader("int x; x1 x bool"))
+	intID, _ := lexer.Pool.Lookup("int")
+	boolID, _ := lexer.Pool.Lookup("bool")
+	x := intern.ID(len(DefaultKeywords) + 1) // the first new ID
+	want := []interface{}{
+		Word{TAG: TYPE, Lexeme: "int", Name: intID},
+		Word{TAG: ID, Lexeme: "x", Name: x},
+		Token{TAG: ';'},
+		Word{TAG: ID, Lexeme: "x1", Name: x + 1},
+		Word{TAG: ID, Lexeme: "x", Name: x},
+		Word{TAG: TYPE, Lexeme: "bool", Name: boolID},
+	}
+	for _, w := range want {
+		tok := lexer.Scan()
+		if word, ok := tok.(Word); ok {
+			word.Pos, word.End = input.Position{}, input.Position{}
+			tok = word
+		} else if c, ok := tok.(Token); ok {
+			c.Pos, c.End = input.Position{}, input.Position{}
+			tok = c
+		}
+		if tok != w {
+			t.Errorf("Scan() = %+v, want %+v", tok, w)
+		}
+	}
+	if lexer.Pool.Len() != len(DefaultKeywords)+2 {
+		t.Errorf("Pool.Len() = %d, want the keywords, x and x1", lexer.Pool.Len())
+	}
+}
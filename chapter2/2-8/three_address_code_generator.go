@@ -2,23 +2,29 @@ package main
 import (
 	"log"
 	"fmt"
-	"io"
 	"unicode"
 	"bytes"
 	"sync/atomic"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter2/2-6/input"
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter2/2-6/token"
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter2/2-7/intern"
 )
 
 /****************************Env*******************************/
 type Env struct {
-	table map[string]*Symbol
+	table map[intern.ID]*Symbol // keyed by the names of the Lexer's Pool
 	pre *Env
 }
 
 func NewEnv(pre *Env) *Env {
-	return &Env{table:map[string]*Symbol{}, pre:pre}
+	return &Env{table:map[intern.ID]*Symbol{}, pre:pre}
 }
 
-func (env *Env) get(key string) *Symbol {
+func (env *Env) get(key intern.ID) *Symbol {
 	for scope := env; scope != nil; scope = scope.pre {
 		if symbol, ok := scope.table[key]; ok {
 			return symbol
@@ -27,8 +33,8 @@ func (env *Env) get(key string) *Symbol {
 	return nil
 }
 
-func (env *Env) put(key string, symbol *Symbol) {
-	if key == "" || symbol == nil {
+func (env *Env) put(key intern.ID, symbol *Symbol) {
+	if key == 0 || symbol == nil {
 		log.Fatalln("Env::put()", "key==", key, ",symbol==", symbol)
 	}
 	env.table[key] = symbol
@@ -69,20 +75,21 @@ func (op *Op) RValue() Node {
 	y := op.Y.RValue()
 	z := op.Z.RValue()
 	op.T = newLabel()
-	var ty Label
-	var tz Label
-	switch y.(type) {
-	case Op:
-		ty = y.(Op).T
-	default:
-		log.Fatalln("Op::RValue():", "y.(type) == " + y.(type))
+	fmt.Printf("t%d = t%d %c t%d\n", op.T, temp(y), op.Tok.TAG, temp(z))
+	return op
 }
-fmt.Println("t" + op.Tok + "=t" + y. + "+t" +)
-return newOp(op.Tok, y, z)
+
+// temp returns the temporary that holds the value of n, which must be an Op.
+func temp(n Node) Label {
+	op, ok := n.(*Op)
+	if !ok {
+		log.Fatalf("temp(): n.(type) == %T", n)
+	}
+	return op.T
 }
 
-func newOp(token Token, y Expr, z Expr) *Op {
-	return &Op{token, y, z}
+func newOp(tok Token, y Expr, z Expr) *Op {
+	return &Op{Tok:tok, Y:y, Z:z}
 }
 
 type Stmt interface {
@@ -94,6 +101,10 @@ var inc int32
 func newLabel() Label {
 	return Label(atomic.AddInt32(&inc, 1))
 }
+
+func (l Label) String() string {
+	return fmt.Sprintf("L%d", int(l))
+}
 /*********************If********************/
 type If struct {
 	E Expr
@@ -102,13 +113,13 @@ type If struct {
 }
 
 func newIf(E Expr, S Stmt) *If {
-	return &If(E, S, newLabel())
+	return &If{E, S, newLabel()}
 }
 func (i *If) Gen() {
 	t := i.E.RValue()
 	fmt.Println("IfFalse", t, "goto", i.After)
 	i.S.Gen()
-	fmt.Println(i.After+":")
+	fmt.Println(i.After.String() + ":")
 }
 /*****************While****************/
 type While struct {
@@ -123,12 +134,12 @@ func newWhile(E Expr, S Stmt) *While {
 }
 
 func (w *While) Gen() {
-	fmt.Println(w.Begain + ":")
+	fmt.Println(w.Begain.String() + ":")
 	t := w.E.RValue()
 	fmt.Println("IfFalse", t, "goto", w.After)
 	w.S.Gen()
 	fmt.Println("goto", w.Begain)
-	fmt.Println(w.After + ":")
+	fmt.Println(w.After.String() + ":")
 }
 /************Do*********************/
 type Do struct {
@@ -151,8 +162,8 @@ type Parser struct {
 	lexer *Lexer
 }
 
-func NewParser() *Parser {
-	parser := &Parser{lexer:NewLexer()}
+func NewParser(lexer *Lexer) *Parser {
+	parser := &Parser{lexer:lexer}
 	parser.lookahead = parser.lexer.Scan()
 	if parser.lookahead == nil {
 		log.Fatalln("NewParser(): no valid input, parser.lookahead == nil")
@@ -196,7 +207,7 @@ func (parser *Parser) decl() {
 
 	s := NewSymbol()
 	s.Type = typ.Lexeme
-	top.put(id.Lexeme, s)
+	top.put(id.Name, s)
 	//	fmt.Println("top put:", top, top.pre, id.Lexeme, s)
 }
 
@@ -244,7 +255,7 @@ func (parser *Parser) stmtsRest() {
 func (parser *Parser) factor() {
 	id := parser.lookahead.(Word)
 	parser.match(id)
-	s := top.get(id.Lexeme)
+	s := top.get(id.Name)
 	if s == nil {
 		log.Fatal("factor():", "top.get(\"", id.Lexeme, "\") returned nil. top == ", top, "\n")
 	}
@@ -252,7 +263,7 @@ func (parser *Parser) factor() {
 }
 
 func (parser *Parser) match(c interface{}) {
-	if parser.lookahead == c {
+	if token.Same(parser.lookahead, c) {
 		//		{
 		//			if t, ok := c.(Token); ok {
 		//				fmt.Printf("\n<%c> matched\n", t.TAG)
@@ -277,140 +288,184 @@ func (parser *Parser) match(c interface{}) {
 	}
 }
 /*********************************Lexer*************************/
-type Tag int
+// The tokens are those that package token defines for all the lexers of
+// chapter 2.
+type (
+	Tag     = token.Tag
+	Token   = token.Token
+	Num     = token.Num
+	Word    = token.Word
+	Comment = token.Comment
+)
 
 const (
-	NUM Tag = 256
-	ID Tag = 257
-	TRUE Tag = 258
-	FALSE Tag = 259
-	TYPE Tag = 260
+	NUM     = token.NUM
+	ID      = token.ID
+	TRUE    = token.TRUE
+	FALSE   = token.FALSE
+	TYPE    = token.TYPE
+	COMMENT = token.COMMENT
 )
 
-type Token struct {
-	TAG Tag
-}
-
 func NewToken(tag Tag) Token {
-	return Token{tag}
-}
-
-type Num struct {
-	TAG Tag
-	Value int
+	return Token{TAG:tag}
 }
 
 func NewNum(value int) Num {
-	return Num{NUM, value}
-}
-
-type Word struct {
-	TAG Tag
-	Lexeme string
+	return Num{TAG:NUM, Value:value}
 }
 
 func NewWord(tag Tag, lexeme string) Word {
-	return Word{tag, lexeme}
+	return Word{TAG:tag, Lexeme:lexeme}
 }
 
 
 type Lexer struct {
-	Words map[string]interface{}
-	line int
-	peek byte
+	Words map[intern.ID]interface{}
+	Pool *intern.Pool // the identifiers and keywords read so far
+	in *input.Reader // the standard input
+	peek rune
+	EmitComments bool   // return comments as Comment instead of skipping them
+	NestedComments bool // let /* */ comments nest
+	IgnoreCase bool     // match keywords whatever the case of their letters
+	folded map[string]Word // the keywords in lower case
+}
+
+// DefaultKeywords are the reserved words and their tags.
+var DefaultKeywords = map[string]Tag{
+	"true":   TRUE,
+	"false":  FALSE,
+	"int":    TYPE,
+	"char":   TYPE,
+	"bool":   TYPE,
+	"double": TYPE,
+	"float":  TYPE,
 }
 
 func NewLexer() *Lexer {
-	return &Lexer{
-		Words:map[string]interface{}{
-			"true": NewWord(TRUE, "true"),
-			"false": NewWord(FALSE, "false"),
-			"int": NewWord(TYPE, "int"),
-			"char": NewWord(TYPE, "char"),
-			"bool": NewWord(TYPE, "bool"),
-			"double": NewWord(TYPE, "double"),
-			"float": NewWord(TYPE, "float"),
-		},
-		line:0,
+	return NewLexerWords(DefaultKeywords)
+}
+
+// NewLexerWords returns a Lexer that reads the identifiers in keywords as
+// words with their tags.
+func NewLexerWords(keywords map[string]Tag) *Lexer {
+	lexer := &Lexer{
+		Words:map[intern.ID]interface{}{},
+		Pool:intern.NewPool(),
+		folded:map[string]Word{},
+		in:input.NewReader("", os.Stdin),
 		peek:' ',
 	}
+	for lexeme, tag := range keywords {
+		word := NewWord(tag, lexeme)
+		word.Name = lexer.Pool.Intern(lexeme)
+		lexer.Words[word.Name] = word
+		lexer.folded[strings.ToLower(lexeme)] = word
+	}
+	return lexer
 }
 
 func (lexer *Lexer) Scan() interface{} {
 	for {
 		// omit the blank symbol
-		if lexer.peek == ' ' || lexer.peek == '\t' {
-			_, err := fmt.Scanf("%c", &lexer.peek)
-			if err == io.EOF {
+		if lexer.peek == ' ' || lexer.peek == '\t' || lexer.peek == '\n' {
+			if !lexer.in.ReadAhead(&lexer.peek) {
 				return nil
 			}
+			continue
+		}
+		pos, end := lexer.in.Pos(), lexer.in.End()
+
+		// process comments
+		if lexer.peek == '/' {
+			text, ok, err := lexer.in.Comment(lexer.NestedComments)
 			if err != nil {
 				log.Fatalln("Scan():", err)
 			}
-			continue
-		} else if lexer.peek == '\n' {
-			lexer.line++
-			_, err := fmt.Scanf("%c", &lexer.peek)
-			if err == io.EOF {
-				return nil
+			lexer.peek = ' '
+			if !ok {
+				return Token{TAG:'/', Pos:pos, End:end}
 			}
-			if err != nil {
-				log.Fatalln("Scan():", err)
+			if lexer.EmitComments {
+				return Comment{TAG:COMMENT, Text:text, Pos:pos, End:lexer.in.End()}
 			}
 			continue
 		}
 
 		// process digits
-		if unicode.IsDigit(rune(lexer.peek)) {
+		if input.IsDigit(lexer.peek) {
 			v := 0
-			for unicode.IsDigit(rune(lexer.peek)) {
+			for input.IsDigit(lexer.peek) {
 				v = v * 10 + int(lexer.peek - '0')
-				_, err := fmt.Scanf("%c", &lexer.peek)
-				if err == io.EOF {
+				if !lexer.in.ReadAhead(&lexer.peek) {
+					lexer.peek = ' '
 					break
 				}
-				if err != nil {
-					log.Fatalln("Scan() process digits:", err)
-				}
 			}
-			return NewNum(v)
+			num := NewNum(v)
+			num.Pos, num.End = pos, lexer.in.Pos()
+			return num
 		}
 
 		// process identifier
 		var w bytes.Buffer
-		if unicode.IsLetter(rune(lexer.peek)) {
-			w.WriteByte(lexer.peek)
+		if unicode.IsLetter(lexer.peek) {
+			w.WriteRune(lexer.peek)
 			for {
-				_, err := fmt.Scanf("%c", &lexer.peek)
-				if err == io.EOF {
+				if !lexer.in.ReadAhead(&lexer.peek) {
+					lexer.peek = ' '
 					break
 				}
-				if err != nil {
-					log.Fatalln("Scan() process identifier: ", err)
-				}
-				if unicode.IsDigit(rune(lexer.peek)) || unicode.IsLetter(rune(lexer.peek)) {
-					w.WriteByte(lexer.peek)
+				if unicode.IsDigit(lexer.peek) || unicode.IsLetter(lexer.peek) {
+					w.WriteRune(lexer.peek)
 				} else {
 					break
 				}
 			}
-			if word, ok := lexer.Words[w.String()]; ok {
+			end = lexer.in.Pos()
+			name := lexer.Pool.InternBytes(w.Bytes())
+			if word, ok := lexer.Words[name]; ok {
+				tok := word.(Word)
+				tok.Pos, tok.End = pos, end
+				return tok
+			}
+			if word, ok := lexer.folded[strings.ToLower(w.String())]; lexer.IgnoreCase && ok {
+				word.Pos, word.End = pos, end
 				return word
 			}
-			word := NewWord(ID, w.String())
-			lexer.Words[w.String()] = word
+			word := NewWord(ID, lexer.Pool.String(name))
+			word.Name = name
+			lexer.Words[name] = word
+			word.Pos, word.End = pos, end
 			return word
 		}
 
 		// process other symbols
 		//		fmt.Printf("\nscaning... <%c>\n", lexer.peek)
-		tok := NewToken(Tag(lexer.peek))
+		tok := Token{TAG:Tag(lexer.peek), Pos:pos, End:end}
 		lexer.peek = ' '
 		return tok
 	}
 }
 
 func main() {
-	parser := NewParser()
+	keywordFile := flag.String("keywords", "", "read the keywords from a keyword table")
+	ignoreCase := flag.Bool("ignorecase", false, "match keywords whatever the case of their letters")
+	flag.Parse()
+	keywords := DefaultKeywords
+	if *keywordFile != "" {
+		file, err := os.Open(*keywordFile)
+		if err != nil {
+			log.Fatalln("main():", err)
+		}
+		keywords, err = token.LoadKeywords(file)
+		file.Close()
+		if err != nil {
+			log.Fatalln("main():", err)
+		}
+	}
+	lexer := NewLexerWords(keywords)
+	lexer.IgnoreCase = *ignoreCase
+	parser := NewParser(lexer)
 	parser.program()
 }
\ No newline at end of file
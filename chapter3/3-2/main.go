@@ -0,0 +1,20 @@
+package main
+
+import (
+	"flag"
+	"log"
+)
+
+// main compares the throughput of the ways a Lexer can read its input, the
+// DoubleBuffer of section 3.2 and the input in memory, on a file of -size MB.
+// That the tokens are the same whatever the size of the buffers and however
+// the input is read is checked by the tests of package lexer, and its
+// benchmarks report the allocations per token of the lexers and of their
+// tokens.
+func main() {
+	size := flag.Int("size", 16, "size in MB of the file lexed")
+	flag.Parse()
+	if err := throughput(*size << 20); err != nil {
+		log.Fatalln("main():", err)
+	}
+}
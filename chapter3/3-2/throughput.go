@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter3/3-4/lexer"
+)
+
+// sample is repeated to make up the input whose lexing is timed.
+const sample = `/* compute the mean */
+while (i < n && total <= 1_000_000) {
+	total = total + x[i] * 2.5e-3; i = i + 1; // next
+	name = "größe\t\x41"; c = 'é';
+}
+if total >= 0xFF then mean = total / n else mean = 0
+`
+
+// modes open the file name in each of the ways a lexer can read it, and
+// return the input together with a function that releases it.
+var modes = []struct {
+	name string
+	open func(name string) (io.Reader, func() error, error)
+}{
+	{"DoubleBuffer", func(name string) (io.Reader, func() error, error) {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return file, file.Close, nil
+	}},
+	{"Memory", func(name string) (io.Reader, func() error, error) {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		m := lexer.NewMemory(name, data)
+		return m, m.Close, nil
+	}},
+	{"MapFile", func(name string) (io.Reader, func() error, error) {
+		m, err := lexer.MapFile(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return m, m.Close, nil
+	}},
+}
+
+// throughput writes a file of size bytes or a little more, and times a Lexer
+// reading it in each of the modes, including the time to open the input.
+func throughput(size int) error {
+	file, err := ioutil.TempFile("", "throughput")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(strings.Repeat(sample, size/len(sample)+1))
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-12s %10s %10s %8s\n", "mode", "tokens", "time", "MB/s")
+	for _, mode := range modes {
+		start := time.Now()
+		input, release, err := mode.open(file.Name())
+		if err != nil {
+			return err
+		}
+		lex, err := lexer.NewLexer(4096, input)
+		if err != nil {
+			release()
+			return err
+		}
+		n := 0
+		for {
+			_, err := lex.NextToken()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				release()
+				return err
+			}
+			n++
+		}
+		elapsed := time.Since(start)
+		if err := release(); err != nil {
+			return err
+		}
+		mb := float64(len(sample)*(size/len(sample)+1)) / (1 << 20)
+		fmt.Printf("%-12s %10d %10v %8.1f\n", mode.name, n, elapsed.Round(time.Millisecond), mb/elapsed.Seconds())
+	}
+	return nil
+}
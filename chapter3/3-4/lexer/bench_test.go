@@ -0,0 +1,134 @@
+package lexer
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// benchText is the input lexed over and over by the benchmarks.
+var benchText = strings.Repeat(`/* compute the mean */
+while (i < n && total <= 1_000_000) {
+	total = total + x[i] * 2.5e-3; i = i + 1; // next
+	name = "größe\t\x41"; c = 'é';
+}
+if total >= 0xFF then mean = total / n else mean = 0
+`, 64)
+
+// benchmarkTokens times next, which returns the next token of a lexer that
+// open makes for benchText, read through a DoubleBuffer and from a Memory.
+// An op is a token: the lexer is made again at the end of benchText with the
+// timer stopped, so that allocs/op counts the allocations per token alone.
+// The Lexer returns Ws tokens too, which the others drop.
+func benchmarkTokens(b *testing.B, open func(input io.Reader) (next func() error, err error)) {
+	for _, memory := range []bool{false, true} {
+		name := "DoubleBuffer"
+		if memory {
+			name = "Memory"
+		}
+		b.Run(name, func(b *testing.B) {
+			var next func() error
+			reopen := func() {
+				b.StopTimer()
+				var input io.Reader = strings.NewReader(benchText)
+				if memory {
+					input = &Memory{Text: benchText}
+				}
+				var err error
+				if next, err = open(input); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+			}
+			reopen()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				err := next()
+				if err == io.EOF {
+					reopen()
+					i--
+					continue
+				}
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func newBenchTable(b *testing.B) *Table {
+	table, err := NewTable(DefaultRules)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return table
+}
+
+func BenchmarkLexerNextToken(b *testing.B) {
+	benchmarkTokens(b, func(input io.Reader) (func() error, error) {
+		lex, err := NewLexer(4096, input)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			_, err := lex.NextToken()
+			return err
+		}, nil
+	})
+}
+
+func BenchmarkLexerNextTok(b *testing.B) {
+	benchmarkTokens(b, func(input io.Reader) (func() error, error) {
+		lex, err := NewLexer(4096, input)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			_, err := lex.NextTok()
+			return err
+		}, nil
+	})
+}
+
+func BenchmarkTableLexerNextToken(b *testing.B) {
+	table := newBenchTable(b)
+	benchmarkTokens(b, func(input io.Reader) (func() error, error) {
+		lex, err := NewTableLexer(table, 4096, input)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			_, err := lex.NextToken()
+			return err
+		}, nil
+	})
+}
+
+func BenchmarkTableLexerNextTok(b *testing.B) {
+	table := newBenchTable(b)
+	benchmarkTokens(b, func(input io.Reader) (func() error, error) {
+		lex, err := NewTableLexer(table, 4096, input)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			_, err := lex.NextTok()
+			return err
+		}, nil
+	})
+}
+
+func BenchmarkSpecScannerNextToken(b *testing.B) {
+	benchmarkTokens(b, func(input io.Reader) (func() error, error) {
+		lex, err := NewSpecScanner(4096, input)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			_, err := lex.NextToken()
+			return err
+		}, nil
+	})
+}
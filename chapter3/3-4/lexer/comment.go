@@ -0,0 +1,88 @@
+package lexer
+
+// nextComment is the diagram for comments (states 56-63), which also yields
+// the operator / in state 34 when no comment follows it. Unless ScanComments
+// is set, a comment is of SkipKind, returned as Ws.
+func (lexer *Lexer) nextComment(ch rune) (Tok, error) {
+	depth := 0
+	state := 56
+	for {
+		lexer.enter(state, ch)
+		switch state {
+		case 56:
+			if ch == '/' {
+				state = 57
+				ch, _ = lexer.df.nextChar()
+			} else {
+				return Tok{}, &InvalidCharError{ch, lexer.df.position(lexer.df.beginOffset)}
+			}
+		case 57:
+			if ch == '/' {
+				state = 58
+				ch, _ = lexer.df.nextChar()
+			} else if ch == '*' {
+				state = 60
+				depth = 1
+				ch, _ = lexer.df.nextChar()
+			} else {
+				state = 34
+			}
+		case 34: // *
+			lexer.df.backword()
+			return lexer.take(OperatorKind, int(DIV)), nil
+		case 58:
+			if ch == '\n' || ch == EOF {
+				state = 59
+			} else {
+				ch, _ = lexer.df.nextChar()
+			}
+		case 59: // *
+			lexer.df.backword()
+			return lexer.comment()
+		case 60:
+			if ch == '*' {
+				state = 61
+				ch, _ = lexer.df.nextChar()
+			} else if ch == '/' && lexer.Mode&NestedComments != 0 {
+				state = 62
+				ch, _ = lexer.df.nextChar()
+			} else if ch == EOF {
+				_, sp := lexer.df.nextLexeme()
+				return Tok{}, &UnterminatedCommentError{sp.begin}
+			} else {
+				ch, _ = lexer.df.nextChar()
+			}
+		case 61:
+			if ch == '/' {
+				depth--
+				if depth == 0 {
+					state = 63
+				} else {
+					state = 60
+					ch, _ = lexer.df.nextChar()
+				}
+			} else if ch == '*' {
+				ch, _ = lexer.df.nextChar()
+			} else {
+				state = 60
+			}
+		case 62:
+			if ch == '*' {
+				depth++
+				ch, _ = lexer.df.nextChar()
+			}
+			state = 60
+		case 63:
+			return lexer.comment()
+		}
+	}
+}
+
+// comment takes the comment between lexemeBegin and forward, of SkipKind
+// unless ScanComments is set.
+func (lexer *Lexer) comment() (Tok, error) {
+	if lexer.Mode&ScanComments != 0 {
+		return lexer.take(CommentKind, 0), nil
+	}
+	return lexer.take(SkipKind, 0), nil
+}
@@ -0,0 +1,172 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter3/3-7/regex"
+)
+
+// Diagram describes one of the transition diagrams of Lexer, which are code,
+// so that it can be drawn; it must be kept in step with the function Name
+// that implements the diagram, which TestDiagrams checks by replaying the
+// steps of the diagrams on inputs that take every edge.
+type Diagram struct {
+	Name   string
+	Start  int
+	Edges  []DiagramEdge
+	States []DiagramState // the accepting states
+}
+
+// DiagramEdge is an edge of a Diagram. An edge labeled "other" is taken on
+// any character that no other edge out of From is labeled with; one labeled
+// ε reads no character, leaving it to be looked at again in To.
+type DiagramEdge struct {
+	From, To int
+	Label    string
+}
+
+// DiagramState is an accepting state of a Diagram. Return is the action taken
+// in it, as in the figures of the book, and Retract is set for the states
+// marked * there, which retract forward by one character.
+type DiagramState struct {
+	State   int
+	Return  string
+	Retract bool
+}
+
+// Diagrams describe the transition diagrams of Lexer.
+var Diagrams = []Diagram{
+	{"nextRelop", 0,
+		[]DiagramEdge{
+			{0, 1, "<"}, {0, 25, "="}, {0, 27, "!"}, {0, 6, ">"},
+			{1, 2, "="}, {1, 3, ">"}, {1, 4, "other"},
+			{6, 7, "="}, {6, 8, "other"},
+			{25, 5, "="}, {25, 26, "other"},
+			{27, 28, "="}, {27, 29, "other"},
+		},
+		[]DiagramState{
+			{2, "return(relop, LE)", false}, {3, "return(relop, NE)", false}, {4, "return(relop, LT)", true},
+			{5, "return(relop, EQ)", false}, {7, "return(relop, GE)", false}, {8, "return(relop, GT)", true},
+			{26, "return(operator, ASSIGN)", true}, {28, "return(relop, NE)", false}, {29, "return(operator, NOT)", true},
+		},
+	},
+	{"nextId", 9,
+		[]DiagramEdge{
+			{9, 10, "letter or _"}, {10, 10, "letter, digit, combining mark or _"}, {10, 11, "other"},
+		},
+		[]DiagramState{
+			{11, "return(getToken(), installID())", true},
+		},
+	},
+	{"nextNumber", 12,
+		[]DiagramEdge{
+			{12, 64, "0"}, {12, 13, "1-9"},
+			{13, 13, "digit"}, {13, 69, "_"}, {13, 14, "."}, {13, 16, "E or e"}, {13, 20, "other"},
+			{14, 15, "digit"},
+			{15, 15, "digit"}, {15, 70, "_"}, {15, 16, "E or e"}, {15, 21, "other"},
+			{16, 17, "+ or -"}, {16, 18, "digit"},
+			{17, 18, "digit"},
+			{18, 18, "digit"}, {18, 71, "_"}, {18, 19, "other"},
+			{64, 65, "x, X, o, O, b or B"}, {64, 13, "ε"},
+			{65, 66, "digit of base"}, {65, 67, "_"},
+			{66, 66, "digit of base"}, {66, 67, "_"}, {66, 68, "other"},
+			{67, 66, "digit of base"},
+			{69, 13, "digit"}, {70, 15, "digit"}, {71, 18, "digit"},
+		},
+		[]DiagramState{
+			{19, "return(number)", true}, {20, "return(number)", true},
+			{21, "return(number)", true}, {68, "return(number)", true},
+		},
+	},
+	{"nextWs", 22,
+		[]DiagramEdge{
+			{22, 23, "delim"}, {23, 23, "delim"}, {23, 24, "other"},
+		},
+		[]DiagramState{
+			{24, "", true},
+		},
+	},
+	{"nextOperator", 30,
+		[]DiagramEdge{
+			{30, 31, "+"}, {30, 32, "-"}, {30, 33, "*"}, {30, 35, "%"}, {30, 36, "&"}, {30, 38, "|"},
+			{36, 37, "&"}, {38, 39, "|"},
+		},
+		[]DiagramState{
+			{31, "return(operator, PLUS)", false}, {32, "return(operator, MINUS)", false},
+			{33, "return(operator, MUL)", false}, {35, "return(operator, MOD)", false},
+			{37, "return(operator, AND)", false}, {39, "return(operator, OR)", false},
+		},
+	},
+	{"nextDelimiter", 40,
+		[]DiagramEdge{
+			{40, 41, "("}, {40, 42, ")"}, {40, 43, "{"}, {40, 44, "}"},
+			{40, 45, "["}, {40, 46, "]"}, {40, 47, ","}, {40, 48, ";"},
+		},
+		[]DiagramState{
+			{41, "return(delimiter, LPAREN)", false}, {42, "return(delimiter, RPAREN)", false},
+			{43, "return(delimiter, LBRACE)", false}, {44, "return(delimiter, RBRACE)", false},
+			{45, "return(delimiter, LBRACKET)", false}, {46, "return(delimiter, RBRACKET)", false},
+			{47, "return(delimiter, COMMA)", false}, {48, "return(delimiter, SEMICOLON)", false},
+		},
+	},
+	{"nextString", 49,
+		[]DiagramEdge{
+			{49, 50, `"`}, {50, 50, `\ escape`}, {50, 50, `other than \n`}, {50, 51, `"`},
+		},
+		[]DiagramState{
+			{51, "return(string)", false},
+		},
+	},
+	{"nextCharLiteral", 52,
+		[]DiagramEdge{
+			{52, 53, "'"}, {53, 54, `\ escape`}, {53, 54, `other than ' and \n`}, {54, 55, "'"},
+		},
+		[]DiagramState{
+			{55, "return(char)", false},
+		},
+	},
+	{"nextComment", 56,
+		[]DiagramEdge{
+			{56, 57, "/"},
+			{57, 58, "/"}, {57, 60, "*"}, {57, 34, "other"},
+			{58, 58, `other than \n`}, {58, 59, `\n or EOF`},
+			{60, 61, "*"}, {60, 62, "/ if nested"}, {60, 60, "other"},
+			{61, 63, "/ closing the outermost"}, {61, 60, "/ closing a nested"}, {61, 61, "*"}, {61, 60, "ε"},
+			{62, 60, "* opening a nested"}, {62, 60, "ε"},
+		},
+		[]DiagramState{
+			{34, "return(operator, DIV)", true}, {59, "return(comment)", true}, {63, "return(comment)", false},
+		},
+	},
+}
+
+// WriteDOT writes the diagram in the DOT language of Graphviz, drawn as in
+// the book: an arrow marked start points to the start state, accepting states
+// have a double circle and retracting ones a * after their number.
+func (d *Diagram) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", d.Name)
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=circle];\n")
+	b.WriteString("\tstart [shape=plaintext];\n")
+	fmt.Fprintf(&b, "\tstart -> %d;\n", d.Start)
+	for _, s := range d.States {
+		label := fmt.Sprint(s.State)
+		if s.Retract {
+			label += " *"
+		}
+		fmt.Fprintf(&b, "\t%d [shape=doublecircle, label=%s", s.State, regex.QuoteDOT(label))
+		if s.Return != "" {
+			fmt.Fprintf(&b, ", xlabel=%s", regex.QuoteDOT(s.Return))
+		}
+		b.WriteString("];\n")
+	}
+	for _, e := range d.Edges {
+		fmt.Fprintf(&b, "\t%d -> %d [label=%s];\n", e.From, e.To, regex.QuoteDOT(e.Label))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,132 @@
+package lexer
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// traceEvents lexes src in mode and returns the events of its diagrams.
+func traceEvents(t *testing.T, src string, mode Mode) []TraceEvent {
+	t.Helper()
+	lex, err := NewLexer(4096, strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lex.Mode = mode | RecoverErrors
+	var events []TraceEvent
+	lex.Trace = func(e TraceEvent) { events = append(events, e) }
+	for {
+		if _, err := lex.NextToken(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("%q: %v", src, err)
+		}
+	}
+	return events
+}
+
+// TestDiagrams replays the steps that the diagrams of Lexer take on inputs
+// that go through every edge of them against Diagrams, which must hold every
+// step taken, and no edge or state that is never reached.
+func TestDiagrams(t *testing.T) {
+	inputs := []struct {
+		src  string
+		mode Mode
+	}{
+		{"a<b<=c<>d<e==f=g!=h!i>j>=k>l", 0},
+		{"x_1 _y größe cafe\u0301 if", 0},
+		{"0 07 1_000 12.5 3.25e+2 4E-1 5e66 6_5.7_8e1_0 0x_F_f 0o17 0B1_0 0x1 0b1z", 0},
+		{" \t\r\n x", 0},
+		{"+-*%&&||/", 0},
+		{"(){}[],;", 0},
+		{`"a\"b\n" 'c' '\x41' '\''`, 0},
+		{"// line\n/* block ** */ /* a /* b */ c */ //", NestedComments},
+		{"/* a /* b */ / * x */", NestedComments},
+		{"a /* b */ c /**/ // d", 0},
+	}
+	edges := map[[2]int]bool{}
+	accepting := map[int]DiagramState{}
+	for _, d := range Diagrams {
+		for _, e := range d.Edges {
+			edges[[2]int{e.From, e.To}] = false
+		}
+		for _, s := range d.States {
+			accepting[s.State] = s
+		}
+	}
+	reached := map[int]bool{}
+	for _, input := range inputs {
+		for _, e := range traceEvents(t, input.src, input.mode) {
+			switch e.Kind {
+			case TraceStep:
+				edge := [2]int{e.State, e.Next}
+				if _, ok := edges[edge]; !ok {
+					t.Errorf("%q: step %v is not an edge of Diagrams", input.src, e)
+				}
+				edges[edge] = true
+			case TraceRetract:
+				if s, ok := accepting[e.State]; ok && !s.Retract {
+					t.Errorf("%q: %v in a state that Diagrams do not mark *", input.src, e)
+				}
+			case TraceAccept:
+				if _, ok := accepting[e.State]; !ok {
+					t.Errorf("%q: %v in a state that Diagrams do not accept in", input.src, e)
+				}
+				reached[e.State] = true
+			}
+		}
+	}
+	for edge, taken := range edges {
+		if !taken {
+			t.Errorf("edge %d -> %d of Diagrams is never taken", edge[0], edge[1])
+		}
+	}
+	for state := range accepting {
+		if !reached[state] {
+			t.Errorf("accepting state %d of Diagrams is never reached", state)
+		}
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	const want = `digraph nextId {
+	rankdir=LR;
+	node [shape=circle];
+	start [shape=plaintext];
+	start -> 9;
+	11 [shape=doublecircle, label="11 *", xlabel="return(getToken(), installID())"];
+	9 -> 10 [label="letter or _"];
+	10 -> 10 [label="letter, digit, combining mark or _"];
+	10 -> 11 [label="other"];
+}
+`
+	var b strings.Builder
+	for i := range Diagrams {
+		if Diagrams[i].Name == "nextId" {
+			if err := Diagrams[i].WriteDOT(&b); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if b.String() != want {
+		t.Errorf("WriteDOT() of nextId:\n%s\nwant\n%s", b.String(), want)
+	}
+
+	// diagrams.dot is what go generate in chapter3/3-4 writes
+	golden, err := ioutil.ReadFile("../diagrams.dot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var all bytes.Buffer
+	for i := range Diagrams {
+		if err := Diagrams[i].WriteDOT(&all); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(all.Bytes(), golden) {
+		t.Errorf("diagrams.dot is not what WriteDOT writes for Diagrams; run go generate in chapter3/3-4")
+	}
+}
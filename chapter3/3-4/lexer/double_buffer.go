@@ -0,0 +1,292 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"unicode/utf8"
+)
+
+type DoubleBuffer struct {
+	buf         [][]byte
+	bufSize     int
+	end         [2]int  // index of the sentinel in each buffer
+	final       [2]bool // the sentinel of the buffer is the end of input
+	lexemeBegin int
+	forward     int
+	curBuf      int
+	isCross     bool  // forward is in the buffer after curBuf
+	loaded      bool  // the buffer after curBuf holds the input following curBuf
+	atEOF       bool  // forward has stepped onto the end of input
+	err         error // first error returned by inputSrc, other than io.EOF
+	inputSrc    io.Reader
+	widths      []int  // widths in bytes of the characters read since lexemeBegin
+	last        string // lexeme most recently returned by nextLexeme
+	inMemory    bool   // the input is a Memory, held in text, and buf is unused
+	text        string // in which forward is the index of the next character
+	trace       func(lexeme string, sp span, accept bool)
+
+	fileName    string
+	offset      int   // input offset of forward
+	beginOffset int   // input offset of lexemeBegin
+	lines       []int // input offsets at which each line starts
+}
+
+// EOF is returned by nextChar at the end of input; it is not a valid rune, so
+// no character of the input can be mistaken for it.
+const EOF rune = -1
+
+// sentinel ends the input loaded in each buffer. The byte 0xFF never occurs
+// in UTF-8; where invalid input contains it anyway, it is told apart from the
+// sentinel by its index, which differs from df.end of its buffer.
+const sentinel byte = 0xFF
+
+func newDoubleBuffer(bufSize int, inputSrc io.Reader) (*DoubleBuffer, error) {
+	if m, ok := inputSrc.(*Memory); ok {
+		// read in place, whatever bufSize
+		return &DoubleBuffer{inMemory: true, text: m.Text, fileName: m.name, lines: []int{0}}, nil
+	}
+	if bufSize <= 1 || inputSrc == nil {
+		return nil, fmt.Errorf("newDoubleBuffer(): bufSize == %d, inputSrc == %v", bufSize, inputSrc)
+	}
+	df := &DoubleBuffer{buf: make([][]byte, 2), bufSize: bufSize}
+	df.buf[0] = make([]byte, bufSize)
+	df.buf[1] = make([]byte, bufSize)
+	df.curBuf = 0
+	df.lexemeBegin = 0
+	df.forward = df.lexemeBegin
+	df.isCross = false
+	df.inputSrc = inputSrc
+	df.lines = []int{0}
+	if named, ok := inputSrc.(interface {
+		Name() string
+	}); ok {
+		df.fileName = named.Name()
+	}
+
+	if err := df.load(df.curBuf); err != nil {
+		return nil, fmt.Errorf("newDoubleBuffer(): %v", err)
+	}
+	return df, nil
+}
+
+// load fills buffer i with the next bufSize - 1 bytes of input and puts the
+// sentinel after them. It reads until the buffer is full, since a pipe or a
+// socket may return fewer bytes than asked for before the end of input; only
+// when the input ends first is the buffer left short and marked final.
+func (df *DoubleBuffer) load(i int) error {
+	n, err := io.ReadFull(df.inputSrc, df.buf[i][:df.bufSize - 1])
+	final := err == io.EOF || err == io.ErrUnexpectedEOF
+	if err != nil && !final {
+		return err
+	}
+	df.buf[i][n] = sentinel
+	df.end[i] = n
+	df.final[i] = final
+	return nil
+}
+
+// nextLexeme returns the lexeme between lexemeBegin and forward together with
+// its span, and starts the next lexeme at forward.
+func (df *DoubleBuffer) nextLexeme() (string, span) {
+	lexeme, sp := df.takeLexeme()
+	if df.trace != nil {
+		df.trace(lexeme, sp, true)
+	}
+	return lexeme, sp
+}
+
+func (df *DoubleBuffer) takeLexeme() (string, span) {
+	sp := span{df.position(df.beginOffset), df.position(df.offset)}
+	df.beginOffset = df.offset
+	df.widths = df.widths[:0]
+	if df.inMemory {
+		df.last = df.text[sp.begin.Offset:df.offset]
+		df.lexemeBegin = df.forward
+		return df.last, sp
+	} else if !df.isCross {
+		lexeme := string(df.buf[df.curBuf][df.lexemeBegin:df.forward])
+		df.lexemeBegin = df.forward
+		df.last = lexeme
+		return lexeme, sp
+	} else {
+		part1 := string(df.buf[df.curBuf][df.lexemeBegin:df.end[df.curBuf]])
+		df.curBuf = (df.curBuf + 1) % 2
+		part2 := string(df.buf[df.curBuf][:df.forward])
+		df.lexemeBegin = df.forward
+		df.isCross = false
+		df.loaded = false
+		df.last = part1 + part2
+		return df.last, sp
+	}
+}
+
+func (df *DoubleBuffer) position(offset int) Position {
+	// lines[i] is the first offset of line i+1
+	i := sort.Search(len(df.lines), func(i int) bool { return df.lines[i] > offset }) - 1
+	return Position{File: df.fileName, Line: i + 1, Column: offset - df.lines[i] + 1, Offset: offset}
+}
+
+// nextChar decodes the UTF-8 character at forward and advances forward past
+// it; a character may start at the end of one buffer and end in the next.
+// Invalid UTF-8 is returned as utf8.RuneError, one byte at a time. At the end
+// of input it returns EOF together with io.EOF; forward is then considered to
+// have stepped past the last character, so a retracting state can always call
+// backword exactly once, whether or not the input ended. A read error is
+// remembered in df.err and reported like the end of input from then on.
+func (df *DoubleBuffer) nextChar() (rune, error) {
+	if df.err != nil {
+		df.atEOF = true
+		return EOF, df.err
+	}
+	if df.inMemory {
+		return df.nextTextChar()
+	}
+	half := df.curBuf
+	if df.isCross {
+		half = (df.curBuf + 1) % 2
+	}
+	b := df.buf[half][df.forward]
+	if b < utf8.RuneSelf {
+		df.advance(1)
+		if b == '\n' && df.lines[len(df.lines) - 1] < df.offset {
+			df.lines = append(df.lines, df.offset)
+		}
+		return rune(b), nil
+	}
+	if b == sentinel && df.forward == df.end[half] {
+		if df.final[half] { // forward is at the end of input
+			df.atEOF = true
+			return EOF, io.EOF
+		}
+		// forward is at the end of a buffer
+		if err := df.enterNext(0); err != nil {
+			return EOF, err
+		}
+		return df.nextChar()
+	}
+	rest := df.buf[half][df.forward:df.end[half]]
+	if utf8.FullRune(rest) || df.final[half] {
+		r, w := utf8.DecodeRune(rest)
+		df.advance(w)
+		return r, nil
+	}
+	// the character may continue in the next buffer
+	if df.isCross {
+		df.grow()
+		return df.nextChar()
+	}
+	if err := df.loadNext(); err != nil {
+		return EOF, err
+	}
+	var p [utf8.UTFMax]byte
+	n := copy(p[:], rest)
+	next := (df.curBuf + 1) % 2
+	n += copy(p[n:], df.buf[next][:df.end[next]])
+	r, w := utf8.DecodeRune(p[:n])
+	if w > len(rest) {
+		if err := df.enterNext(w - len(rest)); err != nil {
+			return EOF, err
+		}
+		df.widths = append(df.widths, w)
+		df.offset += w
+		return r, nil
+	}
+	df.advance(w)
+	return r, nil
+}
+
+// advance moves forward over a character of w bytes within its buffer.
+func (df *DoubleBuffer) advance(w int) {
+	df.forward += w
+	df.offset += w
+	df.widths = append(df.widths, w)
+}
+
+// loadNext loads the input following curBuf into the other buffer, unless it
+// is there already.
+func (df *DoubleBuffer) loadNext() error {
+	if df.loaded {
+		return nil
+	}
+	// load rest input into another buffer
+	if err := df.load((df.curBuf + 1) % 2); err != nil {
+		df.err = fmt.Errorf("DoubleBuffer::nextChar(): load rest input into another buffer, %v", err)
+		df.atEOF = true
+		return df.err
+	}
+	df.loaded = true
+	return nil
+}
+
+// enterNext moves forward from the end of curBuf to index forward of the
+// buffer after it.
+func (df *DoubleBuffer) enterNext(forward int) error {
+	if df.isCross {
+		// the lexeme began in curBuf, which can not be reloaded
+		df.grow()
+	}
+	if err := df.loadNext(); err != nil {
+		return err
+	}
+	df.forward = forward
+	if df.lexemeBegin == df.end[df.curBuf] { // no part of the lexeme is in curBuf
+		df.curBuf = (df.curBuf + 1) % 2
+		df.lexemeBegin = 0
+		df.loaded = false
+	} else {
+		df.isCross = true
+	}
+	return nil
+}
+
+// grow makes room for a lexeme that fills the rest of curBuf and all of the
+// buffer after it: the lexeme is moved to the start of the first of two
+// buffers twice as large, and the second one is left to be loaded with the
+// input that follows. The buffers keep their new size, so that each byte of
+// a long lexeme is copied a bounded number of times on average.
+func (df *DoubleBuffer) grow() {
+	next := (df.curBuf + 1) % 2
+	size := 2 * df.bufSize
+	buf := make([]byte, size)
+	n := copy(buf, df.buf[df.curBuf][df.lexemeBegin:df.end[df.curBuf]])
+	df.forward += n
+	n += copy(buf[n:], df.buf[next][:df.end[next]])
+	buf[n] = sentinel
+	df.buf = [][]byte{buf, make([]byte, size)}
+	df.bufSize = size
+	df.end = [2]int{n, 0}
+	df.final = [2]bool{df.final[next], false}
+	df.curBuf = 0
+	df.lexemeBegin = 0
+	df.isCross = false
+	df.loaded = false
+}
+
+// backword moves forward back over the last character read. It can be called
+// repeatedly, down to lexemeBegin.
+func (df *DoubleBuffer) backword() {
+	if df.trace != nil {
+		df.trace("", span{}, false)
+	}
+	if df.atEOF {
+		df.atEOF = false
+		return
+	}
+	if len(df.widths) == 0 {
+		return
+	}
+	w := df.widths[len(df.widths) - 1]
+	df.widths = df.widths[:len(df.widths) - 1]
+	df.offset -= w
+	if df.isCross {
+		if df.forward >= w {
+			df.forward -= w
+		} else {
+			df.isCross = false
+			df.forward = df.end[df.curBuf] - (w - df.forward)
+		}
+	} else {
+		df.forward -= w
+	}
+}
@@ -0,0 +1,130 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// lexemes are long enough to span many halves of small buffers, and hold
+// multi-byte characters that may be split between two halves.
+var lexemes = []string{
+	`"` + strings.Repeat("long string é ", 7) + `\u00e9"`,
+	"/* " + strings.Repeat("comment * / ", 6) + "*/",
+	"// " + strings.Repeat("größe ", 9),
+	strings.Repeat("größe_", 8) + "1",
+	"0x" + strings.Repeat("F_", 12) + "0",
+	strings.Repeat("1", 30) + ".5e-3",
+	strings.Repeat(" \t", 15),
+	"/* " + strings.Repeat("unterminated ", 4),
+}
+
+// lexAll returns the tokens of src, lexed by a Lexer, then by a TableLexer
+// for table and then by a SpecScanner, with buffers of bufSize bytes that
+// read src through wrap, each formatted with its positions, type and fields.
+func lexAll(t *testing.T, table *Table, src string, bufSize int, wrap func(io.Reader) io.Reader) []string {
+	t.Helper()
+	lex, err := NewLexer(bufSize, wrap(strings.NewReader(src)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lex.Mode = ScanComments
+	tableLex, err := NewTableLexer(table, bufSize, wrap(strings.NewReader(src)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	specScanner, err := NewSpecScanner(bufSize, wrap(strings.NewReader(src)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var toks []string
+	for _, src := range []Source{lex, tableLex, specScanner} {
+		for {
+			tok, err := src.NextToken()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				toks = append(toks, err.Error())
+				continue
+			}
+			toks = append(toks, fmt.Sprintf("%v-%v %T %+v", tok.Pos(), tok.End(), tok, tok))
+		}
+	}
+	return toks
+}
+
+// compareLexing lexes every one of the lexemes, and all of them together,
+// behind every number of blanks that shifts them by less than two buffers,
+// so that they start and end at every offset of the halves, and checks that
+// the tokens read through wrap are those read with a buffer large enough to
+// hold the whole input.
+func compareLexing(t *testing.T, wrap func(io.Reader) io.Reader) {
+	table, err := NewTable(DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := func(r io.Reader) io.Reader { return r }
+	inputs := append([]string{strings.Join(lexemes, "\n")}, lexemes...)
+	for _, input := range inputs {
+		for bufSize := 2; bufSize <= 9; bufSize++ {
+			for pad := 0; pad < 2*bufSize; pad++ {
+				src := strings.Repeat(" ", pad) + input
+				want := lexAll(t, table, src, 4096, plain)
+				got := lexAll(t, table, src, bufSize, wrap)
+				if strings.Join(got, "\n") != strings.Join(want, "\n") {
+					t.Errorf("bufSize %d, %d blanks before %q:\ngot  %q\nwant %q", bufSize, pad, input, got, want)
+				}
+			}
+		}
+	}
+}
+
+// TestBufferBoundaries checks that the tokens are the same whatever the size
+// of the buffers and wherever the lexemes fall in them, and when the input is
+// in memory.
+func TestBufferBoundaries(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		wrap func(io.Reader) io.Reader
+	}{
+		{"Reader", func(r io.Reader) io.Reader { return r }},
+		{"Memory", func(r io.Reader) io.Reader {
+			data, _ := ioutil.ReadAll(r)
+			return NewMemory("", data)
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) { compareLexing(t, test.wrap) })
+	}
+}
+
+// TestShortReads checks that the tokens are the same however few bytes each
+// Read of the input returns, as pipes and sockets may.
+func TestShortReads(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		wrap func(io.Reader) io.Reader
+	}{
+		{"OneByteReader", iotest.OneByteReader},
+		{"HalfReader", iotest.HalfReader},
+		{"DataErrReader", iotest.DataErrReader},
+	} {
+		t.Run(test.name, func(t *testing.T) { compareLexing(t, test.wrap) })
+	}
+}
+
+// TestMemoryBufSize checks that a Memory is lexed in place whatever the size
+// of the buffers asked for, while a Reader needs two bytes at least.
+func TestMemoryBufSize(t *testing.T) {
+	for _, bufSize := range []int{-1, 0, 1} {
+		if _, err := NewLexer(bufSize, NewMemory("", []byte("x = 1"))); err != nil {
+			t.Errorf("bufSize %d, Memory: %v", bufSize, err)
+		}
+		if _, err := NewLexer(bufSize, strings.NewReader("x = 1")); err == nil {
+			t.Errorf("bufSize %d, Reader: no error", bufSize)
+		}
+	}
+}
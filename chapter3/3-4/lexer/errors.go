@@ -0,0 +1,137 @@
+package lexer
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnexpectedEOF is the error that an *UnexpectedEOFError is, as errors.Is
+// reports, whatever its position.
+var ErrUnexpectedEOF = errors.New("lexer: unexpected EOF")
+
+// ErrEmptyCharLiteral is returned for a character literal with no character
+// between its two single quotes.
+var ErrEmptyCharLiteral = errors.New("lexer: empty character literal")
+
+// InvalidCharError is returned when a character cannot start any token.
+type InvalidCharError struct {
+	Char rune
+	Pos  Position
+}
+
+func (e *InvalidCharError) Error() string {
+	return fmt.Sprintf("lexer: %v: invalid character %q", e.Pos, e.Char)
+}
+
+// MalformedNumberError is returned when the number diagram has no transition
+// on Char out of one of its non-accepting states (14, 16, 17 or 65-71), or
+// when Char is a digit too large for the base of the number. Lexeme holds the
+// characters accepted before Char and Pos where they start.
+type MalformedNumberError struct {
+	Lexeme string
+	Char   rune
+	State  int
+	Pos    Position
+}
+
+func (e *MalformedNumberError) Error() string {
+	return fmt.Sprintf("lexer: %v: malformed number %q: unexpected %q in state %d", e.Pos, e.Lexeme, e.Char, e.State)
+}
+
+// UnexpectedEOFError is returned when the input ends while a transition
+// diagram is still in a non-accepting state, e.g. "1.2E" at the end of a
+// file. Lexeme holds the characters read before the end and Pos where they
+// start.
+type UnexpectedEOFError struct {
+	Lexeme string
+	Pos    Position
+}
+
+func (e *UnexpectedEOFError) Error() string {
+	return fmt.Sprintf("lexer: %v: unexpected EOF after %q", e.Pos, e.Lexeme)
+}
+
+// Is reports whether target is ErrUnexpectedEOF.
+func (e *UnexpectedEOFError) Is(target error) bool {
+	return target == ErrUnexpectedEOF
+}
+
+// UnterminatedLiteralError is returned when a string or character literal is
+// not closed before the end of its line or of the input.
+type UnterminatedLiteralError struct {
+	Lexeme string
+	Pos    Position
+}
+
+func (e *UnterminatedLiteralError) Error() string {
+	return fmt.Sprintf("lexer: %v: unterminated literal %s", e.Pos, e.Lexeme)
+}
+
+// LongCharLiteralError is returned for a character literal that holds more
+// than one character, such as 'ab'.
+type LongCharLiteralError struct {
+	Lexeme string
+	Pos    Position
+}
+
+func (e *LongCharLiteralError) Error() string {
+	return fmt.Sprintf("lexer: %v: character literal %s holds more than one character", e.Pos, e.Lexeme)
+}
+
+// InvalidEscapeError is returned for an unknown escape sequence, or one whose
+// hexadecimal digits are missing, in a string or character literal.
+type InvalidEscapeError struct {
+	Escape string
+	Pos    Position
+}
+
+func (e *InvalidEscapeError) Error() string {
+	return fmt.Sprintf("lexer: %v: invalid escape sequence %q", e.Pos, e.Escape)
+}
+
+// UnterminatedCommentError is returned when the input ends inside a /* */
+// comment that starts at Pos.
+type UnterminatedCommentError struct {
+	Pos Position
+}
+
+func (e *UnterminatedCommentError) Error() string {
+	return fmt.Sprintf("lexer: %v: unterminated comment", e.Pos)
+}
+
+// OverflowError reports a number too large for Type, an int64 or a float64.
+// It is recorded as a Diagnostic; the Number is still returned, holding its
+// value as a big.Int or big.Float.
+type OverflowError struct {
+	Lexeme string
+	Type   string
+	Pos    Position
+}
+
+func (e *OverflowError) Error() string {
+	return fmt.Sprintf("lexer: %v: number %s overflows %s", e.Pos, e.Lexeme, e.Type)
+}
+
+// UnderflowError reports a nonzero number too small for a normal float64.
+// If Zero is true it rounds to zero, and the Number holds it as a big.Float;
+// otherwise it holds it as a subnormal float64, which loses precision. It is
+// recorded as a Diagnostic.
+type UnderflowError struct {
+	Lexeme string
+	Zero   bool
+	Pos    Position
+}
+
+func (e *UnderflowError) Error() string {
+	if e.Zero {
+		return fmt.Sprintf("lexer: %v: number %s underflows float64 to zero", e.Pos, e.Lexeme)
+	}
+	return fmt.Sprintf("lexer: %v: number %s loses precision as a subnormal float64", e.Pos, e.Lexeme)
+}
+
+// Diagnostic is a problem found at Pos that did not keep the lexer from
+// returning a token.
+type Diagnostic struct {
+	Pos Position
+	Err error
+}
@@ -0,0 +1,163 @@
+package lexer
+
+import (
+	"io"
+	"sort"
+)
+
+// Edit replaces the Deleted bytes of the input at Offset with Inserted.
+type Edit struct {
+	Offset   int
+	Deleted  int
+	Inserted string
+}
+
+// Change tells which tokens Relex lexed again: the Removed tokens at Index
+// were replaced by Inserted new ones, and the tokens after them were only
+// moved by the edit.
+type Change struct {
+	Index    int
+	Removed  int
+	Inserted int
+}
+
+// Relex returns the tokens of text, an input that edit has made of another,
+// given tokens, those that the lexer returned for that other input. Only the
+// tokens around the edit are lexed again, from the one before the first it
+// reaches, since the lexer may have looked past the end of that one, up to
+// the first token that starts where an old token started once the inserted
+// text is behind: from there on the tokens can only be the old ones, moved,
+// but for those with diagnostics, such as Invalid tokens, which are lexed
+// again for the positions their diagnostics hold. tokens must cover the whole
+// of the old input, as they do in RecoverErrors mode, in which Relex lexes
+// too; tokens itself is not modified.
+//
+// The Diagnostics of the lexer must be those of tokens; afterwards they are
+// those of the tokens of text, as if the lexer had lexed it whole. Its Mode
+// and keywords are those it lexed the old input with; text is lexed in place,
+// as a Memory is. The input of the lexer is left as it was, for NextToken to
+// go on with.
+//
+// What Relex saves is lexing; an edit still costs time linear in the size of
+// text, for Relex finds the line starts of text anew, and makes a copy of
+// every token after the edit to give it its new position. Both passes are
+// far cheaper than lexing, but an editor of large inputs would rather keep
+// the line table from edit to edit and the positions relative to the edits.
+func (lexer *Lexer) Relex(text string, tokens []Token, edit Edit) ([]Token, Change, error) {
+	df := &DoubleBuffer{inMemory: true, text: text, fileName: lexer.df.fileName, lines: []int{0}}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			df.lines = append(df.lines, i+1)
+		}
+	}
+	saved, mode := lexer.df, lexer.Mode
+	lexer.df = df
+	lexer.Mode |= RecoverErrors
+	defer func() { lexer.df, lexer.Mode = saved, mode }()
+
+	first := sort.Search(len(tokens), func(i int) bool { return tokens[i].End().Offset >= edit.Offset })
+	if first > 0 {
+		first--
+	}
+	head := 0 // the old offset from which tokens are lexed again
+	if first < len(tokens) {
+		head = tokens[first].Pos().Offset
+		df.seek(head)
+	}
+	// the diagnostics of the tokens before first are kept; those of the
+	// tokens after it are found again as they are lexed
+	diagnosed := make(map[int]bool) // the old offsets of the diagnostics dropped
+	var diagnostics []Diagnostic
+	for _, d := range lexer.diagnostics {
+		if d.Pos.Offset < head {
+			diagnostics = append(diagnostics, d)
+		} else {
+			diagnosed[d.Pos.Offset] = true
+		}
+	}
+	lexer.diagnostics = diagnostics
+
+	delta := len(edit.Inserted) - edit.Deleted
+	var relexed []Token
+	end := len(tokens)
+	for {
+		if df.beginOffset >= edit.Offset+len(edit.Inserted) {
+			old := df.beginOffset - delta
+			i := sort.Search(len(tokens), func(i int) bool { return tokens[i].Pos().Offset >= old })
+			if i < len(tokens) && tokens[i].Pos().Offset == old {
+				end = i
+				break
+			}
+		}
+		tok, err := lexer.NextToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, Change{}, err
+		}
+		relexed = append(relexed, tok)
+	}
+
+	result := make([]Token, 0, first+len(relexed)+len(tokens)-end)
+	result = append(result, tokens[:first]...)
+	result = append(result, relexed...)
+	for _, tok := range tokens[end:] {
+		begin := tok.Pos().Offset + delta
+		if diagnosed[tok.Pos().Offset] {
+			df.seek(begin)
+			if tok, err := lexer.NextToken(); err == nil {
+				result = append(result, tok)
+				continue
+			}
+		}
+		sp := span{df.position(begin), df.position(tok.End().Offset + delta)}
+		result = append(result, withSpan(tok, sp))
+	}
+	return result, Change{first, end - first, len(relexed)}, nil
+}
+
+// withSpan returns a copy of tok that spans sp.
+func withSpan(tok Token, sp span) Token {
+	switch t := tok.(type) {
+	case *Id:
+		c := *t
+		c.span = sp
+		return &c
+	case *Number:
+		c := *t
+		c.span = sp
+		return &c
+	case *Relop:
+		c := *t
+		c.span = sp
+		return &c
+	case *Operator:
+		c := *t
+		c.span = sp
+		return &c
+	case *Delimiter:
+		c := *t
+		c.span = sp
+		return &c
+	case *String:
+		c := *t
+		c.span = sp
+		return &c
+	case *Char:
+		c := *t
+		c.span = sp
+		return &c
+	case *Comment:
+		c := *t
+		c.span = sp
+		return &c
+	case *Invalid:
+		c := *t
+		c.span = sp
+		return &c
+	case Ws:
+		return Ws{sp}
+	}
+	return tok
+}
@@ -0,0 +1,106 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+// lexText returns the lexer of text in RecoverErrors mode and the tokens it
+// returns.
+func lexText(t *testing.T, text string) (*Lexer, []Token) {
+	t.Helper()
+	lex, err := NewLexer(4096, NewMemory("edit.src", []byte(text)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lex.Mode = RecoverErrors
+	var toks []Token
+	for {
+		tok, err := lex.NextToken()
+		if err == io.EOF {
+			return lex, toks
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		toks = append(toks, tok)
+	}
+}
+
+// describe formats toks and diagnostics with their positions. The Names of
+// identifiers are left out, since they depend on the order in which a lexer
+// met them.
+func describe(toks []Token, diagnostics []Diagnostic) []string {
+	var lines []string
+	for _, tok := range toks {
+		if id, ok := tok.(*Id); ok {
+			c := *id
+			c.Name = 0
+			tok = &c
+		}
+		lines = append(lines, fmt.Sprintf("%v-%v %T %+v", tok.Pos(), tok.End(), tok, tok))
+	}
+	for _, d := range diagnostics {
+		lines = append(lines, fmt.Sprintf("diagnostic %v %v", d.Pos, d.Err))
+	}
+	return lines
+}
+
+// TestRelex checks that Relex returns the tokens and leaves the diagnostics
+// that lexing the edited text whole does, edit after edit.
+func TestRelex(t *testing.T) {
+	const text = "x = 1;\ny = @ 99999999999999999999 + z; /* c */\ns = \"a\\q\" while 1e-400 #"
+	tests := []struct {
+		name    string
+		edits   []Edit
+		changes []Change // of each edit
+	}{
+		{"insert at the start", []Edit{{0, 0, "a "}}, []Change{{0, 0, 2}}},
+		{"insert a line", []Edit{{7, 0, "w = 2;\n"}}, []Change{{5, 2, 9}}},
+		{"delete an error", []Edit{{11, 2, ""}}, []Change{{9, 4, 2}}},
+		{"add an error", []Edit{{4, 1, "$"}}, []Change{{2, 3, 3}}},
+		{"join two tokens", []Edit{{5, 2, ""}}, []Change{{3, 4, 2}}},
+		{"open a comment", []Edit{{6, 0, "/*"}}, []Change{{4, 17, 3}}},
+		{"close a string", []Edit{{55, 0, "\""}}, []Change{{25, 8, 3}}},
+		{"edit at the end", []Edit{{len(text), 0, " 7"}}, []Change{{31, 2, 4}}},
+		{"several edits", []Edit{{0, 1, "xx"}, {20, 0, "\n\n"}, {3, 3, ""}, {10, 0, "0x_"}},
+			[]Change{{0, 1, 1}, {12, 2, 4}, {0, 5, 2}, {7, 2, 2}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lex, toks := lexText(t, text)
+			edited := text
+			for i, edit := range test.edits {
+				edited = edited[:edit.Offset] + edit.Inserted + edited[edit.Offset+edit.Deleted:]
+				old := toks
+				var change Change
+				var err error
+				if toks, change, err = lex.Relex(edited, toks, edit); err != nil {
+					t.Fatal(err)
+				}
+				if change != test.changes[i] {
+					t.Errorf("after %+v, %q: Change %+v, want %+v", edit, edited, change, test.changes[i])
+				}
+				if len(toks) != len(old)-change.Removed+change.Inserted {
+					t.Errorf("after %+v: %d tokens for %d, %+v", edit, len(toks), len(old), change)
+				}
+				for j := 0; j < change.Index && j < len(toks); j++ {
+					if toks[j] != old[j] {
+						t.Errorf("after %+v: token %d %v before the Change is not the old %v", edit, j, toks[j], old[j])
+					}
+				}
+				whole, wholeToks := lexText(t, edited)
+				got, want := describe(toks, lex.Diagnostics()), describe(wholeToks, whole.Diagnostics())
+				for i := 0; i < len(got) || i < len(want); i++ {
+					if i >= len(got) || i >= len(want) || got[i] != want[i] {
+						t.Fatalf("after %+v, %q:\ngot  %q\nwant %q", edit, edited, got[i:], want[i:])
+					}
+				}
+			}
+			if tok, err := lex.NextToken(); err != io.EOF {
+				t.Errorf("NextToken after Relex = %v, %v; want the end of the input lexed before", tok, err)
+			}
+		})
+	}
+}
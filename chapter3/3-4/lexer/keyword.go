@@ -0,0 +1,74 @@
+package lexer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Keywords maps the spelling of each keyword to its code, which must not be
+// REST.
+type Keywords map[string]Keyword
+
+// DefaultKeywords are the keywords of the book's examples.
+var DefaultKeywords = Keywords{
+	"if":    IF,
+	"then":  THEN,
+	"else":  ELSE,
+	"while": WHILE,
+	"do":    DO,
+	"for":   FOR,
+}
+
+// keywordNames are the names of the predefined codes that LoadKeywords
+// accepts.
+var keywordNames = map[string]Keyword{
+	"IF":    IF,
+	"THEN":  THEN,
+	"ELSE":  ELSE,
+	"WHILE": WHILE,
+	"DO":    DO,
+	"FOR":   FOR,
+}
+
+// LoadKeywords reads a keyword table, one keyword a line followed by its
+// code, either a number or one of IF, THEN, ELSE, WHILE, DO and FOR:
+//
+//	// Pascal-like keywords
+//	begin   300
+//	end     301
+//	if      IF
+//
+// Blank lines and lines starting with // are ignored.
+func LoadKeywords(r io.Reader) (Keywords, error) {
+	keywords := Keywords{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "//") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("lexer: keywords:%d: want a keyword and its code, have %q", line, text)
+		}
+		code, ok := keywordNames[fields[1]]
+		if !ok {
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("lexer: keywords:%d: invalid code %q", line, fields[1])
+			}
+			code = Keyword(n)
+		}
+		if code == REST {
+			return nil, fmt.Errorf("lexer: keywords:%d: code of %s is REST", line, fields[0])
+		}
+		keywords[fields[0]] = code
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return keywords, nil
+}
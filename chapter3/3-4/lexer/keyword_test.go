@@ -0,0 +1,75 @@
+package lexer
+
+import (
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLoadKeywords(t *testing.T) {
+	tests := []struct {
+		table string
+		want  Keywords
+		err   string
+	}{
+		{"// Pascal-like keywords\nbegin   300\n\n  end 301  \nif      IF\n", Keywords{"begin": 300, "end": 301, "if": IF}, ""},
+		{"", Keywords{}, ""},
+		{"begin\n", nil, "lexer: keywords:1: want a keyword and its code, have \"begin\""},
+		{"if IF\nbegin 300 301\n", nil, "lexer: keywords:2: want a keyword and its code, have \"begin 300 301\""},
+		{"begin BEGIN\n", nil, "lexer: keywords:1: invalid code \"BEGIN\""},
+		{"rest " + strconv.Itoa(int(REST)) + "\n", nil, "lexer: keywords:1: code of rest is REST"},
+	}
+	for _, test := range tests {
+		got, err := LoadKeywords(strings.NewReader(test.table))
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("LoadKeywords(%q) error = %v, want %s", test.table, err, test.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("LoadKeywords(%q) = %v, %v, want %v", test.table, got, err, test.want)
+		}
+	}
+}
+
+// TestLexerKeywords checks that a Lexer reads the identifiers of a keyword
+// table as those keywords, and no others.
+func TestLexerKeywords(t *testing.T) {
+	keywords, err := LoadKeywords(strings.NewReader("begin 300\nend 301\nif IF\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		mode Mode
+		want []Keyword
+	}{
+		{0, []Keyword{300, REST, 301, IF, REST, REST, REST}},
+		{IgnoreKeywordCase, []Keyword{300, 300, 301, IF, IF, REST, REST}},
+	}
+	for _, test := range tests {
+		lex, err := NewLexerKeywords(16, strings.NewReader("begin BEGIN end if IF then beginning"), keywords)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lex.Mode = test.mode
+		var got []Keyword
+		for {
+			tok, err := lex.NextToken()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id, ok := tok.(*Id); ok {
+				got = append(got, id.Keyword)
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("mode %v: keywords %v, want %v", test.mode, got, test.want)
+		}
+	}
+}
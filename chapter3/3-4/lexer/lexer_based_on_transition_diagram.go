@@ -0,0 +1,620 @@
+// Package lexer is the lexical analyzer of section 3.4, built from the
+// transition diagrams for relop (states 0-8, with = and ! in 25-29), id
+// (9-11), number (12-21, with prefixes and separators in 64-71), whitespace
+// (22-24), operators (30-39), delimiters (40-48), strings (49-51),
+// characters (52-55) and comments (34, 56-63).
+// States marked with * in the book retract forward by one character before
+// the lexeme is taken.
+package lexer
+
+//go:generate go run ../../3-5 -o spec_scanner.go tokens.l
+
+import (
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter2/2-7/intern"
+)
+
+// isIdStart reports whether r can start an identifier: a Unicode letter or _.
+func isIdStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+// isIdPart reports whether r can continue an identifier: besides the
+// characters that start one, a Unicode digit or a combining mark.
+func isIdPart(r rune) bool {
+	return isIdStart(r) || unicode.IsDigit(r) || unicode.In(r, unicode.Mn, unicode.Mc)
+}
+
+// isDigit reports whether r is an ASCII digit; numbers are written only with
+// those.
+func isDigit(r rune) bool {
+	return '0' <= r && r <= '9'
+}
+
+// Mode selects optional behaviour of the Lexer.
+type Mode uint
+
+const (
+	ScanComments   Mode = 1 << iota // return comments as Comment tokens instead of Ws
+	NestedComments                  // let /* */ comments nest
+	RecoverErrors                   // return lexical errors as Invalid tokens
+	IgnoreKeywordCase               // match keywords whatever the case of their letters
+)
+
+type Lexer struct {
+	Mode        Mode
+	Names       *intern.Pool       // the identifiers read so far; a symbol table may share it
+	keywords    *Matcher
+	folded      map[string]Keyword // the keywords in lower case
+	df          *DoubleBuffer
+	diagnostics []Diagnostic
+
+	// Trace, if not nil, is called for every step of the transition
+	// diagrams, see TraceText and TraceJSON.
+	Trace      func(TraceEvent)
+	traceState int // state of the diagram being traced, or -1 before its first
+	traceChar  rune
+}
+
+// NewLexer returns a Lexer for the DefaultKeywords.
+func NewLexer(bufSize int, inputSrc io.Reader) (*Lexer, error) {
+	return NewLexerKeywords(bufSize, inputSrc, DefaultKeywords)
+}
+
+// NewLexerKeywords returns a Lexer that reads the identifiers in keywords as
+// those keywords.
+func NewLexerKeywords(bufSize int, inputSrc io.Reader, keywords Keywords) (*Lexer, error) {
+	lexer := &Lexer{Names:intern.NewPool(), keywords:NewMatcher(keywords), folded:make(map[string]Keyword)}
+	for lexeme, keyword := range keywords {
+		lexer.folded[strings.ToLower(lexeme)] = keyword
+	}
+
+	df, err := newDoubleBuffer(bufSize, inputSrc)
+	if err != nil {
+		return nil, err
+	}
+	lexer.df = df
+	return lexer, nil
+}
+
+// NextToken returns the next token of the input, or nil and io.EOF once the
+// input is exhausted. A lexical error is returned as one of the errors in
+// errors.go; the offending characters are skipped, so the caller may keep
+// calling NextToken after such an error. In RecoverErrors mode the error is
+// instead recorded as a Diagnostic and returned inside an Invalid token, so
+// that only read errors and io.EOF are ever returned.
+func (lexer *Lexer) NextToken() (Token, error) {
+	begin := lexer.df.beginOffset
+	tok, err := lexer.scan()
+	if err == nil {
+		return lexer.token(tok), nil
+	}
+	if err == io.EOF || lexer.df.err != nil || lexer.Mode&RecoverErrors == 0 {
+		return nil, err
+	}
+	return lexer.recover(err, begin), nil
+}
+
+// NextTok is NextToken returning a Tok in place of a Token made of it, as
+// TableLexer.NextTok does: white space, and comments unless ScanComments is
+// set, are dropped, the value of a number is left to the caller to evaluate,
+// an identifier is not interned in Names, and a lexical error is returned
+// whatever the Mode. On a Memory it allocates nothing.
+func (lexer *Lexer) NextTok() (Tok, error) {
+	for {
+		tok, err := lexer.scan()
+		if err != nil || tok.Kind != SkipKind {
+			return tok, err
+		}
+	}
+}
+
+// token makes the Token of tok as NextToken returns it: a Tok of SkipKind is
+// Ws, an Id is interned and the problems with the value of a number are
+// recorded as Diagnostics.
+func (lexer *Lexer) token(tok Tok) Token {
+	if tok.Kind == SkipKind {
+		return Ws{tok.span}
+	}
+	t, err := tok.token()
+	if err != nil {
+		lexer.diagnose(err, tok.begin)
+	}
+	if id, ok := t.(*Id); ok {
+		id.Name = lexer.Names.Intern(id.Lexeme)
+	}
+	return t
+}
+
+// scan runs the transition diagram selected by the first character of the
+// next lexeme, and returns the Tok it takes; white space and comments not
+// returned as such are of SkipKind.
+func (lexer *Lexer) scan() (Tok, error) {
+	if lexer.Trace != nil {
+		lexer.traceState = -1
+		if lexer.df.trace == nil {
+			lexer.df.trace = lexer.traceBuffer
+		}
+	} else {
+		lexer.df.trace = nil
+	}
+	ch, err := lexer.df.nextChar()
+	if err != nil {
+		return Tok{}, err
+	}
+	var tok Tok
+	switch {
+	case isDigit(ch):
+		tok, err = lexer.nextNumber(ch)
+	case isIdStart(ch):
+		tok, err = lexer.nextId(ch)
+	case ch == '<' || ch == '=' || ch == '>' || ch == '!':
+		tok, err = lexer.nextRelop(ch)
+	case ch == '/':
+		tok, err = lexer.nextComment(ch)
+	case ch == '+' || ch == '-' || ch == '*' || ch == '%' || ch == '&' || ch == '|':
+		tok, err = lexer.nextOperator(ch)
+	case ch == '(' || ch == ')' || ch == '{' || ch == '}' || ch == '[' || ch == ']' || ch == ',' || ch == ';':
+		tok, err = lexer.nextDelimiter(ch)
+	case ch == '"':
+		tok, err = lexer.nextString(ch)
+	case ch == '\'':
+		tok, err = lexer.nextCharLiteral(ch)
+	case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+		tok, err = lexer.nextWs(ch)
+	default:
+		_, sp := lexer.df.nextLexeme()
+		return Tok{}, &InvalidCharError{ch, sp.begin}
+	}
+	if lexer.df.err != nil {
+		return Tok{}, lexer.df.err
+	}
+	if err != nil {
+		return Tok{}, err
+	}
+	return tok, nil
+}
+
+// Diagnostics returns the problems found so far that did not keep NextToken
+// from returning a token, such as numbers that overflow 64 bits.
+func (lexer *Lexer) Diagnostics() []Diagnostic {
+	return lexer.diagnostics
+}
+
+// recover is panic-mode recovery from err, found in the lexeme that started at
+// input offset begin. After an invalid character or a malformed number, the
+// characters that follow are deleted as well, up to one at which scanning can
+// resume; the other errors end at such a point already. Everything deleted becomes an Invalid token.
+func (lexer *Lexer) recover(err error, begin int) Token {
+	lexeme := ""
+	if lexer.df.beginOffset > begin {
+		// the diagram has already dropped the start of the lexeme
+		lexeme = lexer.df.last
+	}
+	switch err.(type) {
+	case *InvalidCharError, *MalformedNumberError:
+		lexer.skip()
+	}
+	rest, sp := lexer.df.nextLexeme()
+	pos := lexer.df.position(begin)
+	lexer.diagnose(err, pos)
+	return &Invalid{lexeme + rest, err, span{pos, sp.end}}
+}
+
+// skip advances forward up to the next character at which a token can be
+// resumed: whitespace, the end of input, or one that starts an operator, a
+// delimiter or a literal. Letters and digits are skipped, so that the rest of
+// a malformed word is not taken for a token of its own.
+func (lexer *Lexer) skip() {
+	for {
+		ch, err := lexer.df.nextChar()
+		if err != nil || ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || strings.ContainsRune("<=>!/+-*%&|(){}[],;\"'", ch) {
+			lexer.df.backword()
+			return
+		}
+	}
+}
+
+func (lexer *Lexer) diagnose(err error, pos Position) {
+	lexer.diagnostics = append(lexer.diagnostics, Diagnostic{pos, err})
+}
+
+// take takes the lexeme between lexemeBegin and forward as a Tok of kind and
+// attr.
+func (lexer *Lexer) take(kind TokenKind, attr int) Tok {
+	lexeme, sp := lexer.df.nextLexeme()
+	return Tok{kind, attr, lexeme, sp}
+}
+
+// nextId walks the trie of the keywords along with the diagram, so that
+// where the identifier ends it is known whether it is a keyword.
+func (lexer *Lexer) nextId(ch rune) (Tok, error) {
+	node := 0
+	state := 9
+	for {
+		lexer.enter(state, ch)
+		switch state {
+		case 9:
+			if isIdStart(ch) {
+				state = 10
+				node = lexer.keywords.walk(node, ch)
+				ch, _ = lexer.df.nextChar()
+			} else {
+				return Tok{}, &InvalidCharError{ch, lexer.df.position(lexer.df.beginOffset)}
+			}
+		case 10:
+			if isIdPart(ch) {
+				state = 10
+				node = lexer.keywords.walk(node, ch)
+				ch, _ = lexer.df.nextChar()
+			} else {
+				state = 11
+			}
+		case 11: // *
+			lexer.df.backword()
+			lexeme, sp := lexer.df.nextLexeme()
+			keyword, ok := lexer.keywords.keyword(node)
+			if !ok && lexer.Mode&IgnoreKeywordCase != 0 {
+				if folded, ok := lexer.folded[strings.ToLower(lexeme)]; ok {
+					keyword = folded
+				}
+			}
+			return Tok{IdKind, int(keyword), lexeme, sp}, nil
+		}
+	}
+}
+
+// nextNumber extends the book's diagram with digit separators (states 69-71)
+// and the prefixes 0x, 0o and 0b (states 64-68).
+func (lexer *Lexer) nextNumber(ch rune) (Tok, error) {
+	base := 10
+	state := 12
+	for {
+		lexer.enter(state, ch)
+		switch state {
+		case 12:
+			if ch == '0' {
+				state = 64
+				ch, _ = lexer.df.nextChar()
+			} else if isDigit(ch) {
+				state = 13
+				ch, _ = lexer.df.nextChar()
+			} else {
+				return Tok{}, &InvalidCharError{ch, lexer.df.position(lexer.df.beginOffset)}
+			}
+		case 13:
+			if isDigit(ch) {
+				state = 13
+				ch, _ = lexer.df.nextChar()
+			} else if ch == '_' {
+				state = 69
+				ch, _ = lexer.df.nextChar()
+			} else if ch == '.' {
+				state = 14
+				ch, _ = lexer.df.nextChar()
+			} else if ch == 'E' || ch == 'e' {
+				state = 16
+				ch, _ = lexer.df.nextChar()
+			} else {
+				state = 20
+			}
+		case 14:
+			if isDigit(ch) {
+				state = 15
+				ch, _ = lexer.df.nextChar()
+			} else {
+				return Tok{}, lexer.malformedNumber(ch, state)
+			}
+		case 15:
+			if isDigit(ch) {
+				state = 15
+				ch, _ = lexer.df.nextChar()
+			} else if ch == '_' {
+				state = 70
+				ch, _ = lexer.df.nextChar()
+			} else if ch == 'E' || ch == 'e' {
+				state = 16
+				ch, _ = lexer.df.nextChar()
+			} else {
+				state = 21
+			}
+		case 16:
+			if ch == '+' || ch == '-' {
+				state = 17
+				ch, _ = lexer.df.nextChar()
+			} else if isDigit(ch) {
+				state = 18
+				ch, _ = lexer.df.nextChar()
+			} else {
+				return Tok{}, lexer.malformedNumber(ch, state)
+			}
+		case 17:
+			if isDigit(ch) {
+				state = 18
+				ch, _ = lexer.df.nextChar()
+			} else {
+				return Tok{}, lexer.malformedNumber(ch, state)
+			}
+		case 18:
+			if isDigit(ch) {
+				state = 18
+				ch, _ = lexer.df.nextChar()
+			} else if ch == '_' {
+				state = 71
+				ch, _ = lexer.df.nextChar()
+			} else {
+				state = 19
+			}
+		case 19, 20, 21, 68: // *
+			lexer.df.backword()
+			return lexer.take(NumberKind, base), nil
+		case 64:
+			if ch == 'x' || ch == 'X' {
+				base = 16
+			} else if ch == 'o' || ch == 'O' {
+				base = 8
+			} else if ch == 'b' || ch == 'B' {
+				base = 2
+			}
+			if base != 10 {
+				state = 65
+				ch, _ = lexer.df.nextChar()
+			} else {
+				// a decimal number that starts with 0; ch is looked at again
+				state = 13
+			}
+		case 65, 67:
+			if digitValue(ch) < base {
+				state = 66
+				ch, _ = lexer.df.nextChar()
+			} else if ch == '_' && state == 65 {
+				state = 67
+				ch, _ = lexer.df.nextChar()
+			} else {
+				return Tok{}, lexer.malformedNumber(ch, state)
+			}
+		case 66:
+			if digitValue(ch) < base {
+				state = 66
+				ch, _ = lexer.df.nextChar()
+			} else if ch == '_' {
+				state = 67
+				ch, _ = lexer.df.nextChar()
+			} else if isDigit(ch) {
+				// a digit too large for base, as in 0b102
+				return Tok{}, lexer.malformedNumber(ch, state)
+			} else {
+				state = 68
+			}
+		case 69:
+			// a separator must be followed by a digit
+			if isDigit(ch) {
+				state = 13
+				ch, _ = lexer.df.nextChar()
+			} else {
+				return Tok{}, lexer.malformedNumber(ch, state)
+			}
+		case 70:
+			if isDigit(ch) {
+				state = 15
+				ch, _ = lexer.df.nextChar()
+			} else {
+				return Tok{}, lexer.malformedNumber(ch, state)
+			}
+		case 71:
+			if isDigit(ch) {
+				state = 18
+				ch, _ = lexer.df.nextChar()
+			} else {
+				return Tok{}, lexer.malformedNumber(ch, state)
+			}
+		}
+	}
+}
+
+// malformedNumber retracts the character ch that the number diagram could not
+// accept in state, drops the lexeme read so far and reports why.
+func (lexer *Lexer) malformedNumber(ch rune, state int) error {
+	lexer.df.backword()
+	lexeme, sp := lexer.df.nextLexeme()
+	if ch == EOF {
+		return &UnexpectedEOFError{lexeme, sp.begin}
+	}
+	return &MalformedNumberError{lexeme, ch, state, sp.begin}
+}
+
+// nextRelop also recognizes = and !, which share their first character with
+// the relops == and !=.
+func (lexer *Lexer) nextRelop(ch rune) (Tok, error) {
+	state := 0
+	for {
+		lexer.enter(state, ch)
+		switch state {
+		case 0:
+			if ch == '<' {
+				state = 1
+				ch, _ = lexer.df.nextChar()
+			} else if ch == '=' {
+				state = 25
+				ch, _ = lexer.df.nextChar()
+			} else if ch == '!' {
+				state = 27
+				ch, _ = lexer.df.nextChar()
+			} else if ch == '>' {
+				state = 6
+				ch, _ = lexer.df.nextChar()
+			} else {
+				return Tok{}, &InvalidCharError{ch, lexer.df.position(lexer.df.beginOffset)}
+			}
+		case 1:
+			if ch == '=' {
+				state = 2
+			} else if ch == '>' {
+				state = 3
+			} else {
+				state = 4
+			}
+		case 2:
+			return lexer.take(RelopKind, int(LE)), nil
+		case 3:
+			return lexer.take(RelopKind, int(NE)), nil
+		case 4: // *
+			lexer.df.backword()
+			return lexer.take(RelopKind, int(LT)), nil
+		case 5:
+			return lexer.take(RelopKind, int(EQ)), nil
+		case 6:
+			if ch == '=' {
+				state = 7
+			} else {
+				state = 8
+			}
+		case 7:
+			return lexer.take(RelopKind, int(GE)), nil
+		case 8: // *
+			lexer.df.backword()
+			return lexer.take(RelopKind, int(GT)), nil
+		case 25:
+			if ch == '=' {
+				state = 5
+			} else {
+				state = 26
+			}
+		case 26: // *
+			lexer.df.backword()
+			return lexer.take(OperatorKind, int(ASSIGN)), nil
+		case 27:
+			if ch == '=' {
+				state = 28
+			} else {
+				state = 29
+			}
+		case 28:
+			return lexer.take(RelopKind, int(NE)), nil
+		case 29: // *
+			lexer.df.backword()
+			return lexer.take(OperatorKind, int(NOT)), nil
+		}
+	}
+}
+
+func (lexer *Lexer) nextOperator(ch rune) (Tok, error) {
+	state := 30
+	for {
+		lexer.enter(state, ch)
+		switch state {
+		case 30:
+			if ch == '+' {
+				state = 31
+			} else if ch == '-' {
+				state = 32
+			} else if ch == '*' {
+				state = 33
+			} else if ch == '%' {
+				state = 35
+			} else if ch == '&' {
+				state = 36
+				ch, _ = lexer.df.nextChar()
+			} else if ch == '|' {
+				state = 38
+				ch, _ = lexer.df.nextChar()
+			} else {
+				return Tok{}, &InvalidCharError{ch, lexer.df.position(lexer.df.beginOffset)}
+			}
+		case 31:
+			return lexer.take(OperatorKind, int(PLUS)), nil
+		case 32:
+			return lexer.take(OperatorKind, int(MINUS)), nil
+		case 33:
+			return lexer.take(OperatorKind, int(MUL)), nil
+		case 35:
+			return lexer.take(OperatorKind, int(MOD)), nil
+		case 36:
+			if ch == '&' {
+				state = 37
+			} else {
+				return Tok{}, lexer.loneChar('&')
+			}
+		case 37:
+			return lexer.take(OperatorKind, int(AND)), nil
+		case 38:
+			if ch == '|' {
+				state = 39
+			} else {
+				return Tok{}, lexer.loneChar('|')
+			}
+		case 39:
+			return lexer.take(OperatorKind, int(OR)), nil
+		}
+	}
+}
+
+// loneChar reports the first half ch of a two-character operator that is not
+// followed by its second half; the character after ch is retracted.
+func (lexer *Lexer) loneChar(ch rune) error {
+	lexer.df.backword()
+	_, sp := lexer.df.nextLexeme()
+	return &InvalidCharError{ch, sp.begin}
+}
+
+func (lexer *Lexer) nextDelimiter(ch rune) (Tok, error) {
+	var attribute Attribute
+	state := 40
+	for {
+		lexer.enter(state, ch)
+		switch state {
+		case 40:
+			switch ch {
+			case '(':
+				state, attribute = 41, LPAREN
+			case ')':
+				state, attribute = 42, RPAREN
+			case '{':
+				state, attribute = 43, LBRACE
+			case '}':
+				state, attribute = 44, RBRACE
+			case '[':
+				state, attribute = 45, LBRACKET
+			case ']':
+				state, attribute = 46, RBRACKET
+			case ',':
+				state, attribute = 47, COMMA
+			case ';':
+				state, attribute = 48, SEMICOLON
+			default:
+				return Tok{}, &InvalidCharError{ch, lexer.df.position(lexer.df.beginOffset)}
+			}
+		case 41, 42, 43, 44, 45, 46, 47, 48:
+			return lexer.take(DelimiterKind, int(attribute)), nil
+		}
+	}
+}
+
+func (lexer *Lexer) nextWs(ch rune) (Tok, error) {
+	state := 22
+	for {
+		lexer.enter(state, ch)
+		switch state {
+		case 22:
+			if ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' {
+				state = 23
+				ch, _ = lexer.df.nextChar()
+			} else {
+				return Tok{}, &InvalidCharError{ch, lexer.df.position(lexer.df.beginOffset)}
+			}
+		case 23:
+			if ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' {
+				state = 23
+				ch, _ = lexer.df.nextChar()
+			} else {
+				state = 24
+			}
+		case 24: // *
+			lexer.df.backword()
+			return lexer.take(SkipKind, 0), nil
+		}
+	}
+}
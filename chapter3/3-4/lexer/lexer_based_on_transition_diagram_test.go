@@ -0,0 +1,134 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// recoverTest is an input lexed in RecoverErrors mode, the tokens it is
+// lexed into but white space, each its type and the text it covers, and the
+// errors of the diagnostics.
+type recoverTest struct {
+	src         string
+	toks        []string
+	diagnostics []string
+}
+
+func runRecoverTests(t *testing.T, tests []recoverTest) {
+	t.Helper()
+	for _, test := range tests {
+		lex, err := NewLexer(64, strings.NewReader(test.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lex.Mode = RecoverErrors
+		var toks, diagnostics []string
+		for {
+			tok, err := lex.NextToken()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%q: %v", test.src, err)
+			}
+			if _, ok := tok.(Ws); ok {
+				continue
+			}
+			typ := strings.TrimPrefix(fmt.Sprintf("%T", tok), "*lexer.")
+			toks = append(toks, typ+" "+test.src[tok.Pos().Offset:tok.End().Offset])
+		}
+		for _, d := range lex.Diagnostics() {
+			diagnostics = append(diagnostics, d.Err.Error())
+		}
+		if fmt.Sprint(toks) != fmt.Sprint(test.toks) || fmt.Sprint(diagnostics) != fmt.Sprint(test.diagnostics) {
+			t.Errorf("%q: tokens %q, diagnostics %q\nwant tokens %q, diagnostics %q", test.src, toks, diagnostics, test.toks, test.diagnostics)
+		}
+	}
+}
+
+// TestRecover checks that a lexical error is reported once, in a single
+// Invalid token, and that lexing resumes with the token after it.
+func TestRecover(t *testing.T) {
+	runRecoverTests(t, []recoverTest{
+		{"'ab' x", []string{"Invalid 'ab'", "Id x"},
+			[]string{"lexer: 1:1: character literal 'ab' holds more than one character"}},
+		{`'\xZ' x`, []string{`Invalid '\xZ'`, "Id x"},
+			[]string{`lexer: 1:2: invalid escape sequence "\\x"`}},
+		{`'\q' x`, []string{`Invalid '\q'`, "Id x"},
+			[]string{`lexer: 1:2: invalid escape sequence "\\q"`}},
+		{`'a\q\'b' x`, []string{`Invalid 'a\q\'b'`, "Id x"},
+			[]string{`lexer: 1:3: invalid escape sequence "\\q"`}},
+		{`'a\'b' x`, []string{`Invalid 'a\'b'`, "Id x"},
+			[]string{`lexer: 1:1: character literal 'a\'b' holds more than one character`}},
+		{"'ab\nx", []string{"Invalid 'ab", "Id x"},
+			[]string{"lexer: 1:1: unterminated literal 'ab"}},
+		{"'a", []string{"Invalid 'a"},
+			[]string{"lexer: 1:1: unterminated literal 'a"}},
+		{"'' x", []string{"Invalid ''", "Id x"},
+			[]string{"lexer: empty character literal"}},
+		{"'é' 'b'", []string{"Char 'é'", "Char 'b'"}, nil},
+		{`"a\qb" x`, []string{`Invalid "a\qb"`, "Id x"},
+			[]string{`lexer: 1:3: invalid escape sequence "\\q"`}},
+		{"\"ab\nx", []string{`Invalid "ab`, "Id x"},
+			[]string{`lexer: 1:1: unterminated literal "ab`}},
+		{"a @ b", []string{"Id a", "Invalid @", "Id b"},
+			[]string{"lexer: 1:3: invalid character '@'"}},
+		{"a @bc+d", []string{"Id a", "Invalid @bc", "Operator +", "Id d"},
+			[]string{"lexer: 1:3: invalid character '@'"}},
+		{"1__0x y", []string{"Invalid 1__0x", "Id y"},
+			[]string{`lexer: 1:1: malformed number "1_": unexpected '_' in state 69`}},
+		{"0b12;", []string{"Invalid 0b12", "Delimiter ;"},
+			[]string{`lexer: 1:1: malformed number "0b1": unexpected '2' in state 66`}},
+		{"x /* y", []string{"Id x", "Invalid /* y"},
+			[]string{"lexer: 1:3: unterminated comment"}},
+	})
+}
+
+// TestLoneChar checks that a & or | that is not doubled is an invalid
+// character, whatever follows it, and that lexing goes on with the character
+// after it.
+func TestLoneChar(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string // the tokens but white space, and the errors
+	}{
+		{"&", []string{"lexer: 1:1: invalid character '&'"}},
+		{"|", []string{"lexer: 1:1: invalid character '|'"}},
+		{"& x", []string{"lexer: 1:1: invalid character '&'", "Id x"}},
+		{"a |\tb", []string{"Id a", "lexer: 1:3: invalid character '|'", "Id b"}},
+		{"a &\n", []string{"Id a", "lexer: 1:3: invalid character '&'"}},
+		{"&|", []string{"lexer: 1:1: invalid character '&'", "lexer: 1:2: invalid character '|'"}},
+		{"&(", []string{"lexer: 1:1: invalid character '&'", "Delimiter ("}},
+		{"a&&b||c", []string{"Id a", "Operator &&", "Id b", "Operator ||", "Id c"}},
+	}
+	for _, test := range tests {
+		lex, err := NewLexer(64, strings.NewReader(test.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for {
+			tok, err := lex.NextToken()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				got = append(got, err.Error())
+				continue
+			}
+			if _, ok := tok.(Ws); !ok {
+				typ := strings.TrimPrefix(fmt.Sprintf("%T", tok), "*lexer.")
+				got = append(got, typ+" "+test.src[tok.Pos().Offset:tok.End().Offset])
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("%q: %q, want %q", test.src, got, test.want)
+		}
+	}
+
+	runRecoverTests(t, []recoverTest{
+		{"&", []string{"Invalid &"}, []string{"lexer: 1:1: invalid character '&'"}},
+		{"| x", []string{"Invalid |", "Id x"}, []string{"lexer: 1:1: invalid character '|'"}},
+		{"x |\n", []string{"Id x", "Invalid |"}, []string{"lexer: 1:3: invalid character '|'"}},
+	})
+}
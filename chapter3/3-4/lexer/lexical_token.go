@@ -0,0 +1,168 @@
+package lexer
+
+import (
+	"math/big"
+
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter2/2-7/intern"
+)
+
+type Attribute int
+const (
+	LT Attribute = 256 + iota
+	LE
+	EQ
+	NE
+	GT
+	GE
+)
+
+type Keyword int
+const (
+	IF Keyword = 266 + iota
+	THEN
+	ELSE
+	WHILE
+	DO
+	FOR
+	REST
+)
+
+// attributes of Operator and Delimiter tokens
+const (
+	PLUS Attribute = 280 + iota
+	MINUS
+	MUL
+	DIV
+	MOD
+	ASSIGN
+	NOT
+	AND
+	OR
+	LPAREN
+	RPAREN
+	LBRACE
+	RBRACE
+	LBRACKET
+	RBRACKET
+	COMMA
+	SEMICOLON
+)
+
+type Token interface {
+	Pos() Position // position of the first character of the token
+	End() Position // position just past the last character of the token
+}
+
+// Id is an identifier or a keyword. Name is its lexeme in the Names of the
+// Lexer that read it; it is 0 from a TableLexer or a SpecScanner.
+type Id struct {
+	Keyword Keyword
+	Lexeme  string
+	Name    intern.ID
+	span
+}
+
+func newId(keyword Keyword, lexeme string) *Id {
+	return &Id{Keyword: keyword, Lexeme: lexeme}
+}
+
+// ValueKind tells which field of a Number holds its value.
+type ValueKind int
+
+const (
+	IntValue      ValueKind = iota // Int
+	FloatValue                     // Float
+	BigIntValue                    // BigInt, for an integer that overflows int64
+	BigFloatValue                  // BigFloat, for a number that overflows or underflows float64
+)
+
+type Number struct {
+	Lexeme   string
+	Kind     ValueKind
+	Int      int64
+	Float    float64
+	BigInt   *big.Int
+	BigFloat *big.Float
+	span
+}
+
+func newNumber(lexeme string, sp span) *Number {
+	return &Number{Lexeme: lexeme, span: sp}
+}
+
+type Relop struct {
+	Lexeme    string
+	Attribute Attribute
+	span
+}
+
+func newRelop(lexeme string, attribute Attribute, sp span) *Relop {
+	return &Relop{lexeme, attribute, sp}
+}
+
+// Operator is an arithmetic, logical or assignment operator.
+type Operator struct {
+	Lexeme    string
+	Attribute Attribute
+	span
+}
+
+func newOperator(lexeme string, attribute Attribute, sp span) *Operator {
+	return &Operator{lexeme, attribute, sp}
+}
+
+// Delimiter is one of ( ) { } [ ] , ;
+type Delimiter struct {
+	Lexeme    string
+	Attribute Attribute
+	span
+}
+
+func newDelimiter(lexeme string, attribute Attribute, sp span) *Delimiter {
+	return &Delimiter{lexeme, attribute, sp}
+}
+
+// String is a double-quoted string literal; Value holds its characters with
+// the escape sequences decoded.
+type String struct {
+	Lexeme string
+	Value  string
+	span
+}
+
+func newString(lexeme string, value string, sp span) *String {
+	return &String{lexeme, value, sp}
+}
+
+// Char is a single-quoted character literal.
+type Char struct {
+	Lexeme string
+	Value  rune
+	span
+}
+
+func newChar(lexeme string, value rune, sp span) *Char {
+	return &Char{lexeme, value, sp}
+}
+
+// Comment is a // or /* */ comment, returned only in ScanComments mode.
+type Comment struct {
+	Lexeme string
+	span
+}
+
+func newComment(lexeme string, sp span) *Comment {
+	return &Comment{lexeme, sp}
+}
+
+type Ws struct {
+	span
+}
+
+// Invalid covers the input skipped after a lexical error in RecoverErrors
+// mode; Err is the error, which is also recorded as a Diagnostic.
+type Invalid struct {
+	Lexeme string
+	Err    error
+	span
+}
@@ -0,0 +1,227 @@
+package lexer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// nextString is the diagram for string literals (states 49-51). An invalid
+// escape sequence does not stop the diagram: the literal is read up to its
+// closing quote and the first such error is returned after it. The value of
+// the literal is left to unescape, once its escape sequences are known to be
+// valid.
+func (lexer *Lexer) nextString(ch rune) (Tok, error) {
+	var escErr error
+	state := 49
+	for {
+		lexer.enter(state, ch)
+		switch state {
+		case 49:
+			if ch == '"' {
+				state = 50
+				ch, _ = lexer.df.nextChar()
+			} else {
+				return Tok{}, &InvalidCharError{ch, lexer.df.position(lexer.df.beginOffset)}
+			}
+		case 50:
+			if ch == '"' {
+				state = 51
+			} else if ch == '\\' {
+				if _, _, err := lexer.escape(); err != nil && escErr == nil {
+					escErr = err
+				}
+				ch, _ = lexer.df.nextChar()
+			} else if ch == '\n' || ch == EOF {
+				return Tok{}, lexer.unterminated()
+			} else {
+				ch, _ = lexer.df.nextChar()
+			}
+		case 51:
+			tok := lexer.take(StringKind, 0)
+			if escErr != nil {
+				return Tok{}, escErr
+			}
+			return tok, nil
+		}
+	}
+}
+
+// nextCharLiteral is the diagram for character literals (states 52-55).
+func (lexer *Lexer) nextCharLiteral(ch rune) (Tok, error) {
+	var escErr error
+	state := 52
+	for {
+		lexer.enter(state, ch)
+		switch state {
+		case 52:
+			if ch == '\'' {
+				state = 53
+				ch, _ = lexer.df.nextChar()
+			} else {
+				return Tok{}, &InvalidCharError{ch, lexer.df.position(lexer.df.beginOffset)}
+			}
+		case 53:
+			if ch == '\\' {
+				_, _, escErr = lexer.escape()
+				state = 54
+				ch, _ = lexer.df.nextChar()
+			} else if ch == '\'' {
+				lexer.df.nextLexeme()
+				return Tok{}, ErrEmptyCharLiteral
+			} else if ch == '\n' || ch == EOF {
+				return Tok{}, lexer.unterminated()
+			} else {
+				state = 54
+				ch, _ = lexer.df.nextChar()
+			}
+		case 54:
+			if ch == '\'' {
+				state = 55
+			} else {
+				return Tok{}, lexer.resyncCharLiteral(ch, escErr)
+			}
+		case 55:
+			tok := lexer.take(CharKind, 0)
+			if escErr != nil {
+				return Tok{}, escErr
+			}
+			return tok, nil
+		}
+	}
+}
+
+// unterminated retracts the character that ended a literal too early, drops
+// the literal and reports it.
+func (lexer *Lexer) unterminated() error {
+	lexer.df.backword()
+	lexeme, sp := lexer.df.nextLexeme()
+	return &UnterminatedLiteralError{lexeme, sp.begin}
+}
+
+// resyncCharLiteral recovers from a character literal in which ch follows
+// the first character instead of the closing quote. It skips to that quote,
+// or to the end of the line, which it leaves to the next token, and drops
+// the literal. The one error it reports is that the literal is unterminated,
+// or else the first invalid escape sequence in it, or else that it holds
+// more than one character.
+func (lexer *Lexer) resyncCharLiteral(ch rune, escErr error) error {
+	for ch != '\'' {
+		if ch == '\n' || ch == EOF {
+			return lexer.unterminated()
+		}
+		if ch == '\\' {
+			if _, _, err := lexer.escape(); err != nil && escErr == nil {
+				escErr = err
+			}
+		}
+		ch, _ = lexer.df.nextChar()
+	}
+	lexeme, sp := lexer.df.nextLexeme()
+	if escErr != nil {
+		return escErr
+	}
+	return &LongCharLiteralError{lexeme, sp.begin}
+}
+
+// escape reads an escape sequence whose backslash has just been read, and
+// returns the value it denotes. As in Go, \xhh denotes a single byte, reported
+// with isByte set, while \uhhhh denotes a Unicode code point.
+func (lexer *Lexer) escape() (r rune, isByte bool, err error) {
+	pos := lexer.df.position(lexer.df.offset - 1)
+	ch, _ := lexer.df.nextChar()
+	switch ch {
+	case 'n':
+		return '\n', false, nil
+	case 't':
+		return '\t', false, nil
+	case 'r':
+		return '\r', false, nil
+	case '0':
+		return 0, false, nil
+	case '\\', '"', '\'':
+		return ch, false, nil
+	case 'x':
+		r, err = lexer.hexEscape(ch, 2, pos)
+		return r, true, err
+	case 'u':
+		r, err = lexer.hexEscape(ch, 4, pos)
+		return r, false, err
+	case '\n', EOF:
+		// leave the end of the line to the literal's diagram
+		lexer.df.backword()
+		return utf8.RuneError, false, &InvalidEscapeError{`\`, pos}
+	}
+	return utf8.RuneError, false, &InvalidEscapeError{`\` + string(ch), pos}
+}
+
+// hexEscape reads the n hexadecimal digits of the escape sequence \ followed
+// by kind.
+func (lexer *Lexer) hexEscape(kind rune, n int, pos Position) (rune, error) {
+	text := []byte{'\\', byte(kind)}
+	var r rune
+	for i := 0; i < n; i++ {
+		ch, _ := lexer.df.nextChar()
+		d := digitValue(ch)
+		if d >= 16 {
+			lexer.df.backword()
+			return utf8.RuneError, &InvalidEscapeError{string(text), pos}
+		}
+		text = append(text, byte(ch))
+		r = r*16 + rune(d)
+	}
+	if kind == 'u' && !utf8.ValidRune(r) {
+		return utf8.RuneError, &InvalidEscapeError{string(text), pos}
+	}
+	return r, nil
+}
+
+// charValue returns the character that the literal denotes: a byte, for an
+// escape sequence \xhh, and otherwise the character between its quotes.
+func charValue(literal string) rune {
+	value := unescape(literal)
+	if len(value) == 1 {
+		return rune(value[0])
+	}
+	r, _ := utf8.DecodeRuneInString(value)
+	return r
+}
+
+// unescape returns the characters between the quotes of a literal, with its
+// escape sequences replaced as escape does. The escape sequences must be valid,
+// as they are in the lexemes that a pattern such as that of tokens.l accepts.
+func unescape(literal string) string {
+	var value strings.Builder
+	s := literal[1 : len(literal)-1]
+	for i := 0; i < len(s); {
+		r, w := utf8.DecodeRuneInString(s[i:])
+		i += w
+		if r != '\\' {
+			value.WriteRune(r)
+			continue
+		}
+		switch s[i] {
+		case 'n':
+			value.WriteByte('\n')
+		case 't':
+			value.WriteByte('\t')
+		case 'r':
+			value.WriteByte('\r')
+		case '0':
+			value.WriteByte(0)
+		case 'x':
+			value.WriteByte(byte(digitValue(rune(s[i+1]))<<4 | digitValue(rune(s[i+2]))))
+			i += 2
+		case 'u':
+			var v rune
+			for _, d := range s[i+1 : i+5] {
+				v = v<<4 | rune(digitValue(d))
+			}
+			value.WriteRune(v)
+			i += 4
+		default:
+			value.WriteByte(s[i])
+		}
+		i++
+	}
+	return value.String()
+}
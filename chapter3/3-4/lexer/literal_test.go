@@ -0,0 +1,133 @@
+package lexer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// lexLiteral returns the only token of src, or the error returned in its
+// place.
+func lexLiteral(t *testing.T, src string) (Token, error) {
+	t.Helper()
+	lex, err := NewLexer(64, strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := lex.NextToken()
+	if err != nil {
+		return nil, err
+	}
+	if next, err := lex.NextToken(); err != io.EOF {
+		t.Fatalf("%s: token %#v after the literal", src, next)
+	}
+	return tok, nil
+}
+
+func TestStringValue(t *testing.T) {
+	tests := []struct {
+		src, value string
+	}{
+		{`""`, ""},
+		{`"abc"`, "abc"},
+		{`"a\nb"`, "a\nb"},
+		{`"\t"`, "\t"},
+		{`"\r\0"`, "\r\x00"},
+		{`"\\"`, `\`},
+		{`"\'"`, "'"},
+		{`"\""`, `"`},
+		{`"'"`, "'"},
+		{`"\x41\x7a"`, "Az"},
+		{`"\xff"`, "\xff"},
+		{`"\xFf"`, "\xff"},
+		{`"\u00e9\u4E16"`, "é世"},
+		{`"é"`, "é"},
+		{`"世界"`, "世界"},
+		{`"é\\n"`, `é\n`},
+	}
+	for _, test := range tests {
+		tok, err := lexLiteral(t, test.src)
+		if err != nil {
+			t.Errorf("%s: %v", test.src, err)
+			continue
+		}
+		if s, ok := tok.(*String); !ok || s.Value != test.value || s.Lexeme != test.src {
+			t.Errorf("%s: %#v, want a String with Value %q", test.src, tok, test.value)
+		}
+		if v := unescape(test.src); v != test.value {
+			t.Errorf("unescape(%s) = %q, want %q", test.src, v, test.value)
+		}
+	}
+}
+
+func TestCharValue(t *testing.T) {
+	tests := []struct {
+		src   string
+		value rune
+	}{
+		{`'a'`, 'a'},
+		{`'é'`, 'é'},
+		{`'\n'`, '\n'},
+		{`'\t'`, '\t'},
+		{`'\r'`, '\r'},
+		{`'\0'`, 0},
+		{`'\\'`, '\\'},
+		{`'\''`, '\''},
+		{`'\"'`, '"'},
+		{`'"'`, '"'},
+		{`'\x41'`, 'A'},
+		{`'\xe9'`, 0xe9},
+		{`'\u00e9'`, 'é'},
+		{`'\u4e16'`, '世'},
+		{`'世'`, '世'},
+	}
+	for _, test := range tests {
+		tok, err := lexLiteral(t, test.src)
+		if err != nil {
+			t.Errorf("%s: %v", test.src, err)
+			continue
+		}
+		if c, ok := tok.(*Char); !ok || c.Value != test.value || c.Lexeme != test.src {
+			t.Errorf("%s: %#v, want a Char with Value %q", test.src, tok, test.value)
+		}
+		if v := charValue(test.src); v != test.value {
+			t.Errorf("charValue(%s) = %q, want %q", test.src, v, test.value)
+		}
+	}
+}
+
+func TestEscapeErrors(t *testing.T) {
+	tests := []struct {
+		src    string
+		escape string
+		pos    Position
+	}{
+		{`"\q"`, `\q`, Position{Line: 1, Column: 2, Offset: 1}},
+		{`"ab\x4"`, `\x4`, Position{Line: 1, Column: 4, Offset: 3}},
+		{`"\xg0"`, `\x`, Position{Line: 1, Column: 2, Offset: 1}},
+		{`"\u12"`, `\u12`, Position{Line: 1, Column: 2, Offset: 1}},
+		{`"\ud800"`, `\ud800`, Position{Line: 1, Column: 2, Offset: 1}},
+		{`"\q\x"`, `\q`, Position{Line: 1, Column: 2, Offset: 1}},
+		{`'\a'`, `\a`, Position{Line: 1, Column: 2, Offset: 1}},
+		{`'\x4'`, `\x4`, Position{Line: 1, Column: 2, Offset: 1}},
+		{`'\u00'`, `\u00`, Position{Line: 1, Column: 2, Offset: 1}},
+		{`'\U0001f600'`, `\U`, Position{Line: 1, Column: 2, Offset: 1}},
+		{"x = \"é\\z\"", `\z`, Position{Line: 1, Column: 8, Offset: 7}},
+	}
+	for _, test := range tests {
+		lex, err := NewLexer(64, strings.NewReader(test.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for err == nil {
+			_, err = lex.NextToken()
+		}
+		var escErr *InvalidEscapeError
+		if !errors.As(err, &escErr) {
+			t.Errorf("%s: error %v, want an InvalidEscapeError", test.src, err)
+		} else if escErr.Escape != test.escape || escErr.Pos != test.pos {
+			t.Errorf("%s: error %#v, want escape %s at %#v", test.src, escErr, test.escape, test.pos)
+		}
+	}
+}
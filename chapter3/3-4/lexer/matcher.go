@@ -0,0 +1,153 @@
+package lexer
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// Matcher recognizes the keywords of a keyword table with the trie and the
+// failure function of Aho and Corasick (section 3.4.5). The trie alone tells
+// whether a lexeme is a keyword, which is how the Lexer uses it as it reads
+// an identifier; following the failure function as well, FindAll finds every
+// occurrence of the keywords in a text in a single pass over it.
+type Matcher struct {
+	words  []string       // the keywords, sorted
+	codes  []Keyword      // codes[i] is the code of words[i]
+	next   []map[byte]int // next[s][b] is the child of state s on byte b
+	fail   []int          // fail[s] is the state of the longest proper suffix of the string of s in the trie
+	output []int          // output[s] is the index in words of the string of s, or -1
+	dict   []int          // dict[s] is the first state with an output on the failure chain of s, or 0
+}
+
+// Match is an occurrence of a keyword at Offset of a text.
+type Match struct {
+	Keyword Keyword
+	Lexeme  string
+	Offset  int
+}
+
+// NewMatcher builds the trie of keywords, then computes the failure function
+// breadth-first, as in the book's algorithm for it: the failure of a child of
+// s on b is the child on b of the first state on the failure chain of s that
+// has one.
+func NewMatcher(keywords Keywords) *Matcher {
+	m := &Matcher{}
+	for word := range keywords {
+		m.words = append(m.words, word)
+	}
+	sort.Strings(m.words)
+	m.newState()
+	for i, word := range m.words {
+		m.codes = append(m.codes, keywords[word])
+		s := 0
+		for j := 0; j < len(word); j++ {
+			t, ok := m.next[s][word[j]]
+			if !ok {
+				t = m.newState()
+				m.next[s][word[j]] = t
+			}
+			s = t
+		}
+		m.output[s] = i
+	}
+
+	queue := []int{0}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for b, t := range m.next[s] {
+			queue = append(queue, t)
+			if s == 0 {
+				continue
+			}
+			f := m.fail[s]
+			for f != 0 && !m.has(f, b) {
+				f = m.fail[f]
+			}
+			if u, ok := m.next[f][b]; ok {
+				m.fail[t] = u
+			}
+			if m.output[m.fail[t]] >= 0 {
+				m.dict[t] = m.fail[t]
+			} else {
+				m.dict[t] = m.dict[m.fail[t]]
+			}
+		}
+	}
+	return m
+}
+
+func (m *Matcher) newState() int {
+	m.next = append(m.next, map[byte]int{})
+	m.fail = append(m.fail, 0)
+	m.output = append(m.output, -1)
+	m.dict = append(m.dict, 0)
+	return len(m.next) - 1
+}
+
+func (m *Matcher) has(s int, b byte) bool {
+	_, ok := m.next[s][b]
+	return ok
+}
+
+// walk returns the state of the trie reached from s on the UTF-8 encoding of
+// r, or -1 once no keyword starts with the characters walked over.
+func (m *Matcher) walk(s int, r rune) int {
+	if s < 0 {
+		return -1
+	}
+	var p [utf8.UTFMax]byte
+	for _, b := range p[:utf8.EncodeRune(p[:], r)] {
+		t, ok := m.next[s][b]
+		if !ok {
+			return -1
+		}
+		s = t
+	}
+	return s
+}
+
+// keyword returns the keyword whose string is that of state s, if any.
+func (m *Matcher) keyword(s int) (Keyword, bool) {
+	if s < 0 || m.output[s] < 0 {
+		return REST, false
+	}
+	return m.codes[m.output[s]], true
+}
+
+// Lookup returns the code of lexeme if it is a keyword.
+func (m *Matcher) Lookup(lexeme string) (Keyword, bool) {
+	s := 0
+	for i := 0; i < len(lexeme); i++ {
+		t, ok := m.next[s][lexeme[i]]
+		if !ok {
+			return REST, false
+		}
+		s = t
+	}
+	return m.keyword(s)
+}
+
+// FindAll returns every occurrence of the keywords in text, including those
+// inside longer words and those that overlap, ordered by where they end and,
+// among those that end together, longest first.
+func (m *Matcher) FindAll(text string) []Match {
+	var matches []Match
+	s := 0
+	for i := 0; i < len(text); i++ {
+		b := text[i]
+		for s != 0 && !m.has(s, b) {
+			s = m.fail[s]
+		}
+		if t, ok := m.next[s][b]; ok {
+			s = t
+		}
+		for o := s; o != 0; o = m.dict[o] {
+			if w := m.output[o]; w >= 0 {
+				word := m.words[w]
+				matches = append(matches, Match{m.codes[w], word, i + 1 - len(word)})
+			}
+		}
+	}
+	return matches
+}
@@ -0,0 +1,61 @@
+package lexer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatcherLookup(t *testing.T) {
+	m := NewMatcher(Keywords{"if": IF, "in": 300, "int": 301, "größe": 302})
+	tests := []struct {
+		lexeme string
+		want   Keyword
+		ok     bool
+	}{
+		{"if", IF, true},
+		{"in", 300, true},
+		{"int", 301, true},
+		{"größe", 302, true},
+		{"i", 0, false},
+		{"inte", 0, false},
+		{"IF", 0, false},
+		{"", 0, false},
+	}
+	for _, test := range tests {
+		got, ok := m.Lookup(test.lexeme)
+		if ok != test.ok || ok && got != test.want {
+			t.Errorf("Lookup(%q) = %v, %v, want %v, %v", test.lexeme, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+// TestMatcherFindAll checks FindAll against looking for every keyword at
+// every offset of the text.
+func TestMatcherFindAll(t *testing.T) {
+	tests := []struct {
+		keywords Keywords
+		text     string
+	}{
+		{Keywords{"he": 300, "she": 301, "his": 302, "hers": 303}, "ushers and his shells"},
+		{Keywords{"a": 300, "aa": 301, "aaa": 302}, "aaaa"},
+		{DefaultKeywords, "while (x) do if y then for_each else done"},
+		{Keywords{"größe": 300, "öl": 301}, "größere Ölgrößen größe"},
+		{Keywords{"abc": 300}, ""},
+	}
+	for _, test := range tests {
+		got := NewMatcher(test.keywords).FindAll(test.text)
+		var want []Match
+		for end := 1; end <= len(test.text); end++ {
+			// longest first among those that end together
+			for begin := 0; begin < end; begin++ {
+				lexeme := test.text[begin:end]
+				if code, ok := test.keywords[lexeme]; ok {
+					want = append(want, Match{code, lexeme, begin})
+				}
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("FindAll(%q) with %v:\ngot  %v\nwant %v", test.text, test.keywords, got, want)
+		}
+	}
+}
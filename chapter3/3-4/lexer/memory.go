@@ -0,0 +1,80 @@
+package lexer
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// Memory is an input held in memory as a whole. Given as the inputSrc of
+// NewLexer, NewTableLexer or NewSpecScanner, it is lexed in place: bufSize is
+// ignored, no character is copied into the buffers of a DoubleBuffer, and
+// the lexemes of the tokens are substrings of Text that share its bytes.
+type Memory struct {
+	Text  string
+	name  string
+	read  int          // bytes of Text returned by Read
+	unmap func() error // releases the memory that Text is mapped to, if any
+}
+
+// NewMemory returns a Memory holding a copy of data, for input that is
+// reported as name. The copy keeps the lexemes of its tokens from changing
+// with data, at the cost of allocating len(data) bytes once; text that is
+// already a string is held without a copy as the Text of a Memory literal,
+// and a file is best mapped by MapFile.
+func NewMemory(name string, data []byte) *Memory {
+	return &Memory{Text: string(data), name: name}
+}
+
+func (m *Memory) Name() string {
+	return m.name
+}
+
+// Read makes a Memory an io.Reader, for use where any input is accepted.
+func (m *Memory) Read(p []byte) (int, error) {
+	if m.read >= len(m.Text) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.Text[m.read:])
+	m.read += n
+	return n, nil
+}
+
+// Close releases the memory of a Memory returned by MapFile. The lexemes of
+// its tokens are then no longer valid, so a lexeme that outlives it must be
+// copied first, e.g. with strings.Clone.
+func (m *Memory) Close() error {
+	if m.unmap == nil {
+		return nil
+	}
+	unmap := m.unmap
+	m.Text, m.unmap = "", nil
+	return unmap()
+}
+
+// nextTextChar is nextChar for input in memory, where the end of input is the
+// end of text and no sentinel is needed to find it.
+func (df *DoubleBuffer) nextTextChar() (rune, error) {
+	if df.forward >= len(df.text) {
+		df.atEOF = true
+		return EOF, io.EOF
+	}
+	b := df.text[df.forward]
+	if b < utf8.RuneSelf {
+		df.advance(1)
+		if b == '\n' && df.lines[len(df.lines)-1] < df.offset {
+			df.lines = append(df.lines, df.offset)
+		}
+		return rune(b), nil
+	}
+	r, w := utf8.DecodeRuneInString(df.text[df.forward:])
+	df.advance(w)
+	return r, nil
+}
+
+// seek moves forward in text to offset, as if a lexeme ending there had just
+// been taken.
+func (df *DoubleBuffer) seek(offset int) {
+	df.forward, df.offset, df.beginOffset, df.lexemeBegin = offset, offset, offset, offset
+	df.widths = df.widths[:0]
+	df.atEOF = false
+}
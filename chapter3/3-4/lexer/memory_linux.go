@@ -0,0 +1,42 @@
+//go:build linux
+// +build linux
+
+package lexer
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// MapFile maps the file name into memory with mmap, so that it is lexed in
+// place without being read at all. The file must not change while it is
+// mapped; Close unmaps it.
+func MapFile(name string) (*Memory, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return &Memory{name: name}, nil
+	}
+	if int64(int(size)) != size {
+		return nil, fmt.Errorf("MapFile(): %s is too large to map: %d bytes", name, size)
+	}
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("MapFile(): mmap %s: %v", name, err)
+	}
+	return &Memory{
+		Text:  unsafe.String(&data[0], len(data)),
+		name:  name,
+		unmap: func() error { return syscall.Munmap(data) },
+	}, nil
+}
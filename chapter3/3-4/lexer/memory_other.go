@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package lexer
+
+import "io/ioutil"
+
+// MapFile reads the file name into memory. Only on Linux is the file mapped
+// with mmap instead.
+func MapFile(name string) (*Memory, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewMemory(name, data), nil
+}
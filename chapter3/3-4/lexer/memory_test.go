@@ -0,0 +1,101 @@
+package lexer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMapFile checks that a mapped file is lexed in place as a file read
+// through a DoubleBuffer is lexed, positions included, and then unmapped.
+func TestMapFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, src := range []string{
+		"if größe >= 0x_FF then\n\ts = \"tab\\t\"; c = 'é'\nelse x1 <> 1_000.25e-3 // end\n",
+		"",
+	} {
+		name := filepath.Join(dir, "prog.src")
+		if err := os.WriteFile(name, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		m, err := MapFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Name() != name || m.Text != src {
+			t.Errorf("MapFile(%s) = Name %s, Text %q, want Text %q", name, m.Name(), m.Text, src)
+		}
+		lex, err := NewLexer(0, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := sourceTokens(t, lex, lex.Diagnostics)
+
+		file, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lex, err = NewLexer(16, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := sourceTokens(t, lex, lex.Diagnostics)
+		file.Close()
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("%q: mapped:\n%s\nread:\n%s", src, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+		if src != "" && !strings.HasPrefix(got[0], name+":1:1-"+name+":1:3 ") {
+			t.Errorf("first token %s, want it at %s:1:1", got[0], name)
+		}
+
+		if err := m.Close(); err != nil {
+			t.Errorf("Close() = %v", err)
+		}
+		if m.Text != "" {
+			t.Errorf("Text after Close() = %q, want it empty", m.Text)
+		}
+		if err := m.Close(); err != nil {
+			t.Errorf("second Close() = %v", err)
+		}
+	}
+	if _, err := MapFile(filepath.Join(dir, "missing.src")); !os.IsNotExist(err) {
+		t.Errorf("MapFile() of a missing file: %v, want a not-exist error", err)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	data := []byte("x = 'é'\ny")
+	m := NewMemory("in.src", data)
+	data[0] = 'z'
+	if m.Text != "x = 'é'\ny" {
+		t.Errorf("Text = %q after data changed, want its copy", m.Text)
+	}
+	if m.Name() != "in.src" {
+		t.Errorf("Name() = %q, want in.src", m.Name())
+	}
+
+	var read []byte
+	p := make([]byte, 3)
+	for {
+		n, err := m.Read(p)
+		read = append(read, p[:n]...)
+		if err == io.EOF {
+			break
+		} else if err != nil || n == 0 {
+			t.Fatalf("Read() = %d, %v", n, err)
+		}
+	}
+	if string(read) != m.Text {
+		t.Errorf("Read() returned %q, want %q", read, m.Text)
+	}
+	if n, err := m.Read(p); n != 0 || err != io.EOF {
+		t.Errorf("Read() at the end = %d, %v, want 0, EOF", n, err)
+	}
+
+	// a Memory that was not mapped keeps its text
+	if err := m.Close(); err != nil || m.Text != "x = 'é'\ny" {
+		t.Errorf("Close() = %v, Text %q, want nil and the text kept", err, m.Text)
+	}
+}
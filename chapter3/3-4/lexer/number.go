@@ -0,0 +1,64 @@
+package lexer
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// digitValue returns the value of ch as a hexadecimal digit, or 16 if it is
+// not one.
+func digitValue(ch rune) int {
+	switch {
+	case '0' <= ch && ch <= '9':
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return int(ch - 'a' + 10)
+	case 'A' <= ch && ch <= 'F':
+		return int(ch - 'A' + 10)
+	}
+	return 16
+}
+
+// setNumberValue converts the lexeme of num, an integer written in base or,
+// when base is 10, possibly a floating-point number. A value that overflows
+// int64 or float64 is kept in num.BigInt or num.BigFloat and reported by an
+// *OverflowError. A value too close to zero to be a normal float64 is
+// reported by an *UnderflowError: kept in num.BigFloat if it rounds to zero,
+// or in num.Float as a subnormal number, with fewer significant bits.
+func setNumberValue(num *Number, base int) error {
+	digits := strings.Replace(num.Lexeme, "_", "", -1)
+	if base != 10 {
+		digits = digits[2:] // the prefix 0x, 0o or 0b
+	}
+	if base != 10 || !strings.ContainsAny(digits, ".eE") {
+		if v, err := strconv.ParseInt(digits, base, 64); err == nil {
+			num.Kind = IntValue
+			num.Int = v
+			return nil
+		}
+		num.Kind = BigIntValue
+		num.BigInt, _ = new(big.Int).SetString(digits, base)
+		return &OverflowError{num.Lexeme, "int64", num.Pos()}
+	}
+	v, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		num.Kind = BigFloatValue
+		num.BigFloat, _, _ = big.ParseFloat(digits, 10, 256, big.ToNearestEven)
+		return &OverflowError{num.Lexeme, "float64", num.Pos()}
+	}
+	num.Kind = FloatValue
+	num.Float = v
+	if v == 0 {
+		exact, _, _ := big.ParseFloat(digits, 10, 256, big.ToNearestEven)
+		if exact.Sign() != 0 {
+			num.Kind = BigFloatValue
+			num.BigFloat = exact
+			return &UnderflowError{num.Lexeme, true, num.Pos()}
+		}
+	} else if math.Abs(v) < 0x1p-1022 { // the smallest normal float64
+		return &UnderflowError{num.Lexeme, false, num.Pos()}
+	}
+	return nil
+}
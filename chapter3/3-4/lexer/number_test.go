@@ -0,0 +1,100 @@
+package lexer
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSetNumberValue(t *testing.T) {
+	tests := []struct {
+		lexeme string
+		base   int
+		kind   ValueKind
+		value  string // Int, Float, BigInt or BigFloat, as kind tells
+		err    string
+	}{
+		{"0", 10, IntValue, "0", ""},
+		{"1_000", 10, IntValue, "1000", ""},
+		{"0x_Ff", 16, IntValue, "255", ""},
+		{"0XfF", 16, IntValue, "255", ""},
+		{"0b101", 2, IntValue, "5", ""},
+		{"0o17", 8, IntValue, "15", ""},
+		{"9223372036854775807", 10, IntValue, "9223372036854775807", ""},
+		{"9223372036854775808", 10, BigIntValue, "9223372036854775808",
+			"lexer: 1:1: number 9223372036854775808 overflows int64"},
+		{"0x1_0000_0000_0000_0000", 16, BigIntValue, "18446744073709551616",
+			"lexer: 1:1: number 0x1_0000_0000_0000_0000 overflows int64"},
+		{"0b1" + strings.Repeat("0", 64), 2, BigIntValue, "18446744073709551616",
+			"lexer: 1:1: number 0b1" + strings.Repeat("0", 64) + " overflows int64"},
+		{"1.5", 10, FloatValue, "1.5", ""},
+		{"1_000.25e-3", 10, FloatValue, "1.00025", ""},
+		{"15E+2", 10, FloatValue, "1500", ""},
+		{"0.0", 10, FloatValue, "0", ""},
+		{"0e-400", 10, FloatValue, "0", ""},
+		{"1.7976931348623157e308", 10, FloatValue, "1.7976931348623157e+308", ""},
+		{"1e309", 10, BigFloatValue, "1e+309", "lexer: 1:1: number 1e309 overflows float64"},
+		{"2.2250738585072014e-308", 10, FloatValue, "2.2250738585072014e-308", ""},
+		{"1e-310", 10, FloatValue, "1e-310",
+			"lexer: 1:1: number 1e-310 loses precision as a subnormal float64"},
+		{"5e-324", 10, FloatValue, "5e-324",
+			"lexer: 1:1: number 5e-324 loses precision as a subnormal float64"},
+		{"1e-400", 10, BigFloatValue, "1e-400", "lexer: 1:1: number 1e-400 underflows float64 to zero"},
+	}
+	for _, test := range tests {
+		num := newNumber(test.lexeme, span{begin: Position{Line: 1, Column: 1}})
+		err := setNumberValue(num, test.base)
+		var value string
+		switch num.Kind {
+		case IntValue:
+			value = strconv.FormatInt(num.Int, 10)
+		case FloatValue:
+			value = strconv.FormatFloat(num.Float, 'g', -1, 64)
+		case BigIntValue:
+			value = num.BigInt.String()
+		case BigFloatValue:
+			value = num.BigFloat.Text('g', 10)
+		}
+		if num.Kind != test.kind || value != test.value {
+			t.Errorf("%s: kind %d, value %s, want kind %d, value %s", test.lexeme, num.Kind, value, test.kind, test.value)
+		}
+		if test.err == "" && err != nil || test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("%s: error %v, want %s", test.lexeme, err, test.err)
+		}
+	}
+}
+
+// TestMalformedNumber checks the errors of the number diagram, which never
+// hands setNumberValue a malformed lexeme.
+func TestMalformedNumber(t *testing.T) {
+	tests := []struct {
+		src, err string
+	}{
+		{"1__0", `lexer: 1:1: malformed number "1_": unexpected '_' in state 69`},
+		{"0x;", `lexer: 1:1: malformed number "0x": unexpected ';' in state 65`},
+		{"0x_;", `lexer: 1:1: malformed number "0x_": unexpected ';' in state 67`},
+		{"0o8", `lexer: 1:1: malformed number "0o": unexpected '8' in state 65`},
+		{"0b102", `lexer: 1:1: malformed number "0b10": unexpected '2' in state 66`},
+		{"1.e5", `lexer: 1:1: malformed number "1.": unexpected 'e' in state 14`},
+		{"1e+;", `lexer: 1:1: malformed number "1e+": unexpected ';' in state 17`},
+		{"0x", `lexer: 1:1: unexpected EOF after "0x"`},
+		{"x = 1.2E", `lexer: 1:5: unexpected EOF after "1.2E"`},
+	}
+	for _, test := range tests {
+		lex, err := NewLexer(64, strings.NewReader(test.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tok Token
+		for err == nil {
+			tok, err = lex.NextToken()
+		}
+		if err.Error() != test.err {
+			t.Errorf("%s: %#v, %v, want error %s", test.src, tok, err, test.err)
+		}
+		if isEOF := strings.Contains(test.err, "EOF"); errors.Is(err, ErrUnexpectedEOF) != isEOF {
+			t.Errorf("%s: errors.Is(%v, ErrUnexpectedEOF) = %v", test.src, err, !isEOF)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package lexer
+
+import "fmt"
+
+// Position is a location in the input. Line and Column start at 1 and Column
+// counts bytes; Offset is the number of bytes before the location.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+	Offset int
+}
+
+func (pos Position) String() string {
+	if pos.File == "" {
+		return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", pos.File, pos.Line, pos.Column)
+}
+
+// span is embedded in every token and records where its lexeme starts and
+// the position just past its last character.
+type span struct {
+	begin Position
+	end   Position
+}
+
+func (sp span) Pos() Position {
+	return sp.begin
+}
+
+func (sp span) End() Position {
+	return sp.end
+}
@@ -0,0 +1,95 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// sourceTokens returns the tokens of src, formatted with their positions,
+// type and fields, or the errors returned in their place, followed by the
+// diagnostics. White space is left out, and the Name of an Id, which only a
+// Lexer sets.
+func sourceTokens(t *testing.T, src Source, diagnostics func() []Diagnostic) []string {
+	t.Helper()
+	var toks []string
+	for {
+		tok, err := src.NextToken()
+		if err == io.EOF {
+			break
+		}
+		switch tok := tok.(type) {
+		case nil:
+			toks = append(toks, err.Error())
+			continue
+		case Ws:
+			continue
+		case *Id:
+			tok.Name = 0
+		}
+		toks = append(toks, fmt.Sprintf("%v-%v %T %+v", tok.Pos(), tok.End(), tok, tok))
+	}
+	for _, d := range diagnostics() {
+		toks = append(toks, fmt.Sprintf("%v: %v", d.Pos, d.Err))
+	}
+	return toks
+}
+
+// TestSpecScanner checks that the scanner generated from tokens.l makes the
+// same tokens as a TableLexer for its DefaultRules, errors included, and as
+// the transition diagrams of a Lexer on input free of lexical errors, which
+// the diagrams report in their own way.
+func TestSpecScanner(t *testing.T) {
+	table, err := NewTable(DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := []string{
+		benchText,
+		strings.Join(lexemes[:len(lexemes)-1], "\n"),
+		"if x1 >= 0x_FF then y = 1_000.25e-3; else z <> 0b1010\n" +
+			"while (größe != 0o17 && ok || !done) { n = n % 3 * 2 / 1 - 7 + 0; }\n" +
+			`s = "tab\t \"quoted\" \x41 é"; c = '\n'; d = 'é'; e = '\''` + "\n" +
+			"for [a, b] do ifx _if iF",
+		"99999999999999999999 1e999 1e-400 0x1_0000_0000_0000_0000",
+		"a<b<=c<>d==e=f!g!=h>i>=j",
+		"",
+	}
+	invalid := []string{
+		`x = 'ab' y @ "a\q" 1__0 0x & | '` + "\n",
+		"/* unterminated",
+		`"unterminated` + "\nx",
+		"1.e5 1e+ 0b2 0o8",
+	}
+	for _, src := range append(valid, invalid...) {
+		tableLex, err := NewTableLexer(table, 64, strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		specScanner, err := NewSpecScanner(64, strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := sourceTokens(t, tableLex, tableLex.Diagnostics)
+		got := sourceTokens(t, specScanner, specScanner.Diagnostics)
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("%q: SpecScanner:\n%s\nTableLexer:\n%s", src, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+	for _, src := range valid {
+		lex, err := NewLexer(64, strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		specScanner, err := NewSpecScanner(64, strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := sourceTokens(t, lex, lex.Diagnostics)
+		got := sourceTokens(t, specScanner, specScanner.Diagnostics)
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("%q: SpecScanner:\n%s\nLexer:\n%s", src, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+}
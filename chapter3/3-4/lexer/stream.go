@@ -0,0 +1,60 @@
+package lexer
+
+import (
+	"context"
+	"io"
+)
+
+// Source returns the tokens of its input one at a time, as Lexer,
+// TableLexer and SpecScanner do.
+type Source interface {
+	NextToken() (Token, error)
+}
+
+// Item is a token sent by Stream, or the error returned in its place.
+type Item struct {
+	Token Token
+	Err   error
+}
+
+// Stream calls src.NextToken in a goroutine of its own and sends what it
+// returns on the channel it returns, which holds up to size items that the
+// receiver has not taken yet, so that a parser can work on earlier tokens
+// while later ones are being lexed. Lexical errors, such as those in
+// errors.go, are sent as Items and lexing goes on after them; any other error
+// is sent as the last Item. The channel is closed at the end of input, after
+// such an error, or once ctx is done. A parser that gives up cancels ctx; the
+// goroutine then stops before its next send, though not in the middle of a
+// Read of the input. src must not be used by anyone else until the channel
+// is closed.
+func Stream(ctx context.Context, src Source, size int) <-chan Item {
+	items := make(chan Item, size)
+	go func() {
+		defer close(items)
+		for ctx.Err() == nil {
+			tok, err := src.NextToken()
+			if err == io.EOF {
+				return
+			}
+			select {
+			case items <- Item{tok, err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil && !isLexical(err) {
+				return
+			}
+		}
+	}()
+	return items
+}
+
+// isLexical reports whether err is an error in the input that the lexer has
+// skipped, so that it can go on to the next token.
+func isLexical(err error) bool {
+	switch err.(type) {
+	case *InvalidCharError, *MalformedNumberError, *UnexpectedEOFError, *UnterminatedLiteralError, *LongCharLiteralError, *InvalidEscapeError, *UnterminatedCommentError:
+		return true
+	}
+	return err == ErrEmptyCharLiteral
+}
@@ -0,0 +1,104 @@
+package lexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// source returns the tokens and errors of items, then io.EOF, or endless
+// Ws tokens if items is nil.
+type source struct {
+	items []Item
+}
+
+func (src *source) NextToken() (Token, error) {
+	if src.items == nil {
+		return Ws{}, nil
+	}
+	if len(src.items) == 0 {
+		return nil, io.EOF
+	}
+	item := src.items[0]
+	src.items = src.items[1:]
+	return item.Token, item.Err
+}
+
+func describeItems(items []Item) []string {
+	var lines []string
+	for _, item := range items {
+		if item.Err != nil {
+			lines = append(lines, item.Err.Error())
+		} else {
+			lines = append(lines, fmt.Sprintf("%T %v-%v", item.Token, item.Token.Pos(), item.Token.End()))
+		}
+	}
+	return lines
+}
+
+// TestStream checks that Stream sends what NextToken returns, lexical errors
+// included, and stops after any other error.
+func TestStream(t *testing.T) {
+	const text = "a @ 0x b"
+	lex, err := NewLexer(4, strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []Item
+	for {
+		tok, err := lex.NextToken()
+		if err == io.EOF {
+			break
+		}
+		want = append(want, Item{tok, err})
+	}
+	readErr := errors.New("read error")
+	tests := []struct {
+		name string
+		src  Source
+		want []Item
+	}{
+		{"Lexer", nil, want},
+		{"read error", &source{[]Item{want[0], {nil, readErr}, want[1]}}, []Item{want[0], {nil, readErr}}},
+		{"empty", &source{[]Item{}}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := test.src
+			if src == nil {
+				if src, err = NewLexer(4, strings.NewReader(text)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var got []Item
+			for item := range Stream(context.Background(), src, 2) {
+				got = append(got, item)
+			}
+			g, w := describeItems(got), describeItems(test.want)
+			if strings.Join(g, "\n") != strings.Join(w, "\n") {
+				t.Errorf("got  %q\nwant %q", g, w)
+			}
+		})
+	}
+}
+
+// TestStreamCancel checks that the channel is closed once the context is
+// done, however much input is left.
+func TestStreamCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	items := Stream(ctx, &source{}, 4)
+	for i := 0; i < 10; i++ {
+		<-items
+	}
+	cancel()
+	n := 0
+	for range items {
+		n++
+	}
+	if n > 5 {
+		t.Errorf("%d items received after cancel, want at most the 4 buffered and 1 being sent", n)
+	}
+}
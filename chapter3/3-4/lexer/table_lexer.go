@@ -0,0 +1,218 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter3/3-7/regex"
+)
+
+// TokenKind tells a TableLexer which token to make of the lexemes of a Rule.
+type TokenKind int
+
+const (
+	SkipKind      TokenKind = iota // no token: the lexeme is dropped
+	IdKind                         // an Id whose Keyword is Attr
+	NumberKind                     // a Number written in base Attr
+	RelopKind                      // a Relop whose Attribute is Attr
+	OperatorKind                   // an Operator whose Attribute is Attr
+	DelimiterKind                  // a Delimiter whose Attribute is Attr
+	StringKind                     // a String
+	CharKind                       // a Char
+	CommentKind                    // a Comment
+)
+
+// Rule is a pattern in the syntax of package regex and the token made of the
+// lexemes it matches.
+type Rule struct {
+	Pattern string
+	Kind    TokenKind
+	Attr    int
+}
+
+// Table is the transition table of the minimal DFA for a list of rules. Its
+// input symbols are ranges of characters: symbol i holds the characters
+// Bounds[2*i] through Bounds[2*i+1].
+type Table struct {
+	Rules  []Rule
+	Bounds []rune
+	Trans  [][]int // Trans[s][i] is the state entered from s on symbol i, or -1
+	Accept []int   // the rule accepted in each state, or -1
+	Start  int
+
+	ascii [utf8.RuneSelf]int // the symbol of each ASCII character, or -1
+}
+
+// NewTable compiles rules into a Table. A state of the DFA that accepts the
+// lexemes of several rules accepts the earliest of them. No rule may match
+// the empty string.
+func NewTable(rules []Rule) (*Table, error) {
+	nfas := make([]*regex.NFA, len(rules))
+	for i, rule := range rules {
+		nfa, err := regex.Compile(rule.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		nfas[i] = nfa
+	}
+	dfa := regex.Combine(nfas...).DFA().Minimize()
+	if rule := dfa.Accept[dfa.Start]; rule >= 0 {
+		return nil, fmt.Errorf("lexer: rule %d, %s, matches the empty string", rule, rules[rule].Pattern)
+	}
+	t := &Table{Rules: rules, Trans: dfa.Trans, Accept: dfa.Accept, Start: dfa.Start}
+	for _, r := range dfa.Alphabet {
+		t.Bounds = append(t.Bounds, r.Lo, r.Hi)
+	}
+	for ch := range t.ascii {
+		t.ascii[ch] = t.search(rune(ch))
+	}
+	return t, nil
+}
+
+// symbol returns the input symbol of ch, or -1 if no rule uses ch.
+func (t *Table) symbol(ch rune) int {
+	if 0 <= ch && ch < utf8.RuneSelf {
+		return t.ascii[ch]
+	}
+	return t.search(ch)
+}
+
+func (t *Table) search(ch rune) int {
+	n := len(t.Bounds) / 2
+	i := sort.Search(n, func(i int) bool { return t.Bounds[2*i+1] >= ch })
+	if i < n && t.Bounds[2*i] <= ch {
+		return i
+	}
+	return -1
+}
+
+// TableLexer is a lexical analyzer driven by a Table instead of transition
+// diagrams written as code.
+type TableLexer struct {
+	table       *Table
+	df          *DoubleBuffer
+	diagnostics []Diagnostic
+}
+
+func NewTableLexer(table *Table, bufSize int, inputSrc io.Reader) (*TableLexer, error) {
+	df, err := newDoubleBuffer(bufSize, inputSrc)
+	if err != nil {
+		return nil, err
+	}
+	return &TableLexer{table: table, df: df}, nil
+}
+
+// NextToken returns the next token of the input, or nil and io.EOF once the
+// input is exhausted. The token is made of the longest lexeme that a rule
+// matches, by the earliest rule that matches it; when no rule matches, one
+// character is skipped and reported by an *InvalidCharError.
+func (lexer *TableLexer) NextToken() (Token, error) {
+	tok, err := lexer.NextTok()
+	if err != nil {
+		return nil, err
+	}
+	t, err := tok.token()
+	if err != nil {
+		lexer.diagnose(err, tok.begin)
+	}
+	return t, nil
+}
+
+// Tok is a token as a value: the Kind and Attr of the Rule, or of the
+// diagram, that matched its Lexeme, which is left to the caller to evaluate.
+// Returning one allocates nothing, and on a Memory its Lexeme is a substring
+// of the Text, so that lexing there with NextTok allocates nothing either; on
+// a DoubleBuffer, a Lexeme longer than one byte is copied out of its buffers.
+type Tok struct {
+	Kind   TokenKind
+	Attr   int
+	Lexeme string
+	span
+}
+
+// NextTok is NextToken returning a Tok in place of a Token made of it.
+func (lexer *TableLexer) NextTok() (Tok, error) {
+	for {
+		rule := lexer.match()
+		if lexer.df.err != nil {
+			return Tok{}, lexer.df.err
+		}
+		if rule < 0 {
+			ch, err := lexer.df.nextChar()
+			if err != nil {
+				return Tok{}, err
+			}
+			_, sp := lexer.df.nextLexeme()
+			return Tok{}, &InvalidCharError{ch, sp.begin}
+		}
+		lexeme, sp := lexer.df.nextLexeme()
+		if r := lexer.table.Rules[rule]; r.Kind != SkipKind {
+			return Tok{r.Kind, r.Attr, lexeme, sp}, nil
+		}
+	}
+}
+
+// match runs the DFA from lexemeBegin for as long as it has transitions,
+// which is maximal munch, then retracts forward to the end of the last lexeme
+// accepted and returns the rule that accepted it, or -1.
+func (lexer *TableLexer) match() int {
+	t := lexer.table
+	rule, back := -1, 0 // back counts the characters read past the lexeme of rule
+	for state := t.Start; state >= 0; {
+		if t.Accept[state] >= 0 {
+			rule, back = t.Accept[state], 0
+		}
+		ch, _ := lexer.df.nextChar()
+		back++
+		if a := t.symbol(ch); a >= 0 {
+			state = t.Trans[state][a]
+		} else {
+			state = -1
+		}
+	}
+	for ; back > 0; back-- {
+		lexer.df.backword()
+	}
+	return rule
+}
+
+// token makes the Token of tok. It returns with it the problem found in the
+// lexeme that did not keep the token from being made, if any, such as an
+// *OverflowError.
+func (tok Tok) token() (Token, error) {
+	lexeme, sp := tok.Lexeme, tok.span
+	switch tok.Kind {
+	case IdKind:
+		id := newId(Keyword(tok.Attr), lexeme)
+		id.span = sp
+		return id, nil
+	case NumberKind:
+		num := newNumber(lexeme, sp)
+		return num, setNumberValue(num, tok.Attr)
+	case RelopKind:
+		return newRelop(lexeme, Attribute(tok.Attr), sp), nil
+	case OperatorKind:
+		return newOperator(lexeme, Attribute(tok.Attr), sp), nil
+	case DelimiterKind:
+		return newDelimiter(lexeme, Attribute(tok.Attr), sp), nil
+	case StringKind:
+		return newString(lexeme, unescape(lexeme), sp), nil
+	case CharKind:
+		return newChar(lexeme, charValue(lexeme), sp), nil
+	case CommentKind:
+		return newComment(lexeme, sp), nil
+	}
+	return nil, nil
+}
+
+// Diagnostics returns the problems found so far that did not keep NextToken
+// from returning a token, such as numbers that overflow 64 bits.
+func (lexer *TableLexer) Diagnostics() []Diagnostic {
+	return lexer.diagnostics
+}
+
+func (lexer *TableLexer) diagnose(err error, pos Position) {
+	lexer.diagnostics = append(lexer.diagnostics, Diagnostic{pos, err})
+}
@@ -0,0 +1,78 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestNextTok checks that the Tok returned by NextTok makes the Token that
+// NextToken returns, from a Lexer and from a TableLexer alike.
+func TestNextTok(t *testing.T) {
+	src := benchText + `'\xff' 'é' 'a' "a\x41é\"" 99999999999999999999 0b1_0 // end`
+	table, err := NewTable(DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lexers := []struct {
+		name string
+		open func() (next func() (Token, error), nextTok func() (Tok, error))
+	}{
+		{"Lexer", func() (func() (Token, error), func() (Tok, error)) {
+			lex, _ := NewLexer(64, strings.NewReader(src))
+			lexTok, _ := NewLexer(64, strings.NewReader(src))
+			lex.Mode, lexTok.Mode = ScanComments, ScanComments
+			return lex.NextToken, lexTok.NextTok
+		}},
+		{"TableLexer", func() (func() (Token, error), func() (Tok, error)) {
+			lex, _ := NewTableLexer(table, 64, strings.NewReader(src))
+			lexTok, _ := NewTableLexer(table, 64, strings.NewReader(src))
+			return lex.NextToken, lexTok.NextTok
+		}},
+	}
+	for _, lexer := range lexers {
+		t.Run(lexer.name, func(t *testing.T) {
+			next, nextTok := lexer.open()
+			var want, got []string
+			var chars []rune
+			for {
+				tok, err := next()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				switch tok := tok.(type) {
+				case Ws:
+					continue
+				case *Id:
+					tok.Name = 0
+				case *Char:
+					chars = append(chars, tok.Value)
+				}
+				want = append(want, fmt.Sprintf("%T %+v", tok, tok))
+			}
+			for {
+				tok, err := nextTok()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				if tok.Kind == SkipKind {
+					t.Fatalf("NextTok returned %+v", tok)
+				}
+				made, _ := tok.token()
+				got = append(got, fmt.Sprintf("%T %+v", made, made))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("NextTok made\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+			if tail := chars[len(chars)-3:]; !reflect.DeepEqual(tail, []rune{0xff, 'é', 'a'}) {
+				t.Errorf("Char values %q, want %q", tail, []rune{0xff, 'é', 'a'})
+			}
+		})
+	}
+}
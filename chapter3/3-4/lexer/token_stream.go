@@ -0,0 +1,100 @@
+package lexer
+
+import "io"
+
+// TokenStream gives a parser lookahead of any number of tokens over a Source,
+// and lets it back up to parse the same tokens again. Ws tokens are dropped,
+// since a parser has no use for them.
+type TokenStream struct {
+	src   Source
+	items []Item // tokens read from src that may still be returned again
+	next  int    // index in items of the token that Next returns
+	marks []int  // indices in items saved by Mark, innermost last
+	done  bool   // src has returned io.EOF or an error other than a lexical one
+}
+
+func NewTokenStream(src Source) *TokenStream {
+	return &TokenStream{src: src}
+}
+
+// Next returns the next token and moves past it. Like Source.NextToken, it
+// returns nil and io.EOF once the input is exhausted, and a lexical error in
+// place of a token.
+func (ts *TokenStream) Next() (Token, error) {
+	if len(ts.marks) == 0 && ts.next > 1 {
+		// only the token that Unread gives back is kept
+		ts.items = ts.items[ts.next-1:]
+		ts.next = 1
+	}
+	item := ts.peek(0)
+	if item.Err != io.EOF {
+		ts.next++
+	}
+	return item.Token, item.Err
+}
+
+// Peek returns the token n tokens ahead without moving past it: Peek(1) is
+// the token that Next returns, Peek(2) the one after it, and so on. Past the
+// end of input it returns nil and io.EOF.
+func (ts *TokenStream) Peek(n int) (Token, error) {
+	if n < 1 {
+		panic("lexer: TokenStream.Peek: n < 1")
+	}
+	item := ts.peek(n - 1)
+	return item.Token, item.Err
+}
+
+// peek reads tokens from src until the one i tokens after next is in items,
+// and returns it.
+func (ts *TokenStream) peek(i int) Item {
+	for ts.next+i >= len(ts.items) {
+		if ts.done {
+			return ts.items[len(ts.items)-1]
+		}
+		tok, err := ts.src.NextToken()
+		if _, ok := tok.(Ws); ok && err == nil {
+			continue
+		}
+		if err != nil && !isLexical(err) {
+			ts.done = true
+		}
+		ts.items = append(ts.items, Item{tok, err})
+	}
+	return ts.items[ts.next+i]
+}
+
+// Unread gives back the token that Next returned last, so that Next returns
+// it again. It can be called repeatedly back to the innermost Mark or, when
+// there is none, at least once after each call of Next; it returns false when
+// there is no token to give back.
+func (ts *TokenStream) Unread() bool {
+	min := 0
+	if len(ts.marks) > 0 {
+		min = ts.marks[len(ts.marks)-1]
+	}
+	if ts.next <= min {
+		return false
+	}
+	ts.next--
+	return true
+}
+
+// Mark saves the place of the next token, so that a parser can try one
+// alternative of a production and then Reset to try another. Every Mark must
+// be matched by a Reset or a Release; marks nest.
+func (ts *TokenStream) Mark() {
+	ts.marks = append(ts.marks, ts.next)
+}
+
+// Reset goes back to the place saved by the innermost Mark and drops that
+// mark.
+func (ts *TokenStream) Reset() {
+	ts.next = ts.marks[len(ts.marks)-1]
+	ts.marks = ts.marks[:len(ts.marks)-1]
+}
+
+// Release drops the innermost Mark without going back to it, once the parser
+// has committed to the tokens read since.
+func (ts *TokenStream) Release() {
+	ts.marks = ts.marks[:len(ts.marks)-1]
+}
@@ -0,0 +1,90 @@
+package lexer
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTokenStream(t *testing.T) {
+	const text = "a = 1; b @ c"
+	// each step calls a method of the TokenStream: for Next and Peek, want is
+	// the lexeme of the token returned, EOF or the error; for Unread, "true"
+	// or "false".
+	steps := []struct {
+		call string
+		n    int
+		want string
+	}{
+		{"Peek", 1, "a"},
+		{"Peek", 3, "1"},
+		{"Next", 0, "a"},
+		{"Unread", 0, "true"},
+		{"Unread", 0, "false"},
+		{"Next", 0, "a"},
+		{"Next", 0, "="},
+		{"Mark", 0, ""},
+		{"Next", 0, "1"},
+		{"Mark", 0, ""},
+		{"Next", 0, ";"},
+		{"Reset", 0, ""},
+		{"Unread", 0, "true"},
+		{"Unread", 0, "false"},
+		{"Next", 0, "1"},
+		{"Next", 0, ";"},
+		{"Next", 0, "b"},
+		{"Reset", 0, ""},
+		{"Next", 0, "1"},
+		{"Mark", 0, ""},
+		{"Next", 0, ";"},
+		{"Release", 0, ""},
+		{"Next", 0, "b"},
+		{"Peek", 1, "lexer: 1:10: invalid character '@'"},
+		{"Peek", 3, "EOF"},
+		{"Next", 0, "lexer: 1:10: invalid character '@'"},
+		{"Next", 0, "c"},
+		{"Next", 0, "EOF"},
+		{"Next", 0, "EOF"},
+		{"Unread", 0, "true"},
+		{"Next", 0, "c"},
+	}
+	lex, err := NewLexer(4, strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := NewTokenStream(lex)
+	for i, step := range steps {
+		var tok Token
+		var err error
+		got := ""
+		switch step.call {
+		case "Next":
+			tok, err = ts.Next()
+		case "Peek":
+			tok, err = ts.Peek(step.n)
+		case "Unread":
+			if ts.Unread() {
+				got = "true"
+			} else {
+				got = "false"
+			}
+		case "Mark":
+			ts.Mark()
+		case "Reset":
+			ts.Reset()
+		case "Release":
+			ts.Release()
+		}
+		switch {
+		case err == io.EOF:
+			got = "EOF"
+		case err != nil:
+			got = err.Error()
+		case tok != nil:
+			got = text[tok.Pos().Offset:tok.End().Offset]
+		}
+		if got != step.want {
+			t.Fatalf("step %d, %s(%d) = %q, want %q", i, step.call, step.n, got, step.want)
+		}
+	}
+}
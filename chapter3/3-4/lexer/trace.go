@@ -0,0 +1,125 @@
+package lexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// TraceKind tells what a TraceEvent reports.
+type TraceKind int
+
+const (
+	TraceStep    TraceKind = iota // the diagram went from State to Next on Char
+	TraceRetract                  // State retracted forward over Char
+	TraceAccept                   // State took Lexeme, which starts at Pos
+)
+
+var traceKindNames = [...]string{"step", "retract", "accept"}
+
+func (k TraceKind) String() string {
+	return traceKindNames[k]
+}
+
+// TraceEvent is a step of a transition diagram reported to Lexer.Trace. Char
+// is the character that State looked at, EOF at the end of input; a step that
+// Char does not label, such as one to a state marked *, is taken on any
+// character other than those on the other edges of State.
+type TraceEvent struct {
+	Kind   TraceKind
+	State  int
+	Char   rune
+	Next   int    // for TraceStep
+	Lexeme string // for TraceAccept
+	Pos    Position
+}
+
+func (e TraceEvent) String() string {
+	switch e.Kind {
+	case TraceStep:
+		return fmt.Sprintf("%3d %-8s -> %d", e.State, quoteChar(e.Char), e.Next)
+	case TraceRetract:
+		return fmt.Sprintf("%3d %-8s retract", e.State, quoteChar(e.Char))
+	}
+	return fmt.Sprintf("%3d accept %s at %v", e.State, strconv.Quote(e.Lexeme), e.Pos)
+}
+
+func quoteChar(ch rune) string {
+	if ch == EOF {
+		return "EOF"
+	}
+	return strconv.QuoteRune(ch)
+}
+
+// TraceText returns a Lexer.Trace that writes every event to w on a line of
+// its own, for reading.
+func TraceText(w io.Writer) func(TraceEvent) {
+	return func(e TraceEvent) {
+		fmt.Fprintln(w, e)
+	}
+}
+
+// TraceJSON returns a Lexer.Trace that writes every event to w as a JSON
+// object on a line of its own, such as
+//
+//	{"event":"step","state":12,"char":"1","next":13}
+//	{"event":"accept","state":13,"lexeme":"12","line":1,"column":1,"offset":0}
+//
+// where char is null for EOF.
+func TraceJSON(w io.Writer) func(TraceEvent) {
+	enc := json.NewEncoder(w)
+	return func(e TraceEvent) {
+		var ch *string
+		if e.Char != EOF {
+			s := string(e.Char)
+			ch = &s
+		}
+		switch e.Kind {
+		case TraceStep:
+			enc.Encode(struct {
+				Event string  `json:"event"`
+				State int     `json:"state"`
+				Char  *string `json:"char"`
+				Next  int     `json:"next"`
+			}{e.Kind.String(), e.State, ch, e.Next})
+		case TraceRetract:
+			enc.Encode(struct {
+				Event string  `json:"event"`
+				State int     `json:"state"`
+				Char  *string `json:"char"`
+			}{e.Kind.String(), e.State, ch})
+		case TraceAccept:
+			enc.Encode(struct {
+				Event  string `json:"event"`
+				State  int    `json:"state"`
+				Lexeme string `json:"lexeme"`
+				Line   int    `json:"line"`
+				Column int    `json:"column"`
+				Offset int    `json:"offset"`
+			}{e.Kind.String(), e.State, e.Lexeme, e.Pos.Line, e.Pos.Column, e.Pos.Offset})
+		}
+	}
+}
+
+// enter is called by a diagram each time it is in state looking at ch, and
+// reports the step that led there from the previous state.
+func (lexer *Lexer) enter(state int, ch rune) {
+	if lexer.Trace == nil {
+		return
+	}
+	if lexer.traceState >= 0 {
+		lexer.Trace(TraceEvent{Kind: TraceStep, State: lexer.traceState, Char: lexer.traceChar, Next: state})
+	}
+	lexer.traceState, lexer.traceChar = state, ch
+}
+
+// traceBuffer is called by the DoubleBuffer when forward is retracted and
+// when a lexeme is taken.
+func (lexer *Lexer) traceBuffer(lexeme string, sp span, accept bool) {
+	if !accept {
+		lexer.Trace(TraceEvent{Kind: TraceRetract, State: lexer.traceState, Char: lexer.traceChar})
+		return
+	}
+	lexer.Trace(TraceEvent{Kind: TraceAccept, State: lexer.traceState, Lexeme: lexeme, Pos: sp.begin})
+}
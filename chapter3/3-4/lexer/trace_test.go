@@ -0,0 +1,128 @@
+package lexer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+// traceOutput lexes src with the Trace made by trace and returns what it
+// wrote.
+func traceOutput(t *testing.T, src string, trace func(io.Writer) func(TraceEvent)) string {
+	t.Helper()
+	lex, err := NewLexer(4096, strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	lex.Trace = trace(&out)
+	for {
+		if _, err := lex.NextToken(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("%q: %v", src, err)
+		}
+	}
+	return out.String()
+}
+
+func TestTraceText(t *testing.T) {
+	want := `
+ 12 '1'      -> 13
+ 13 '2'      -> 13
+ 13 '<'      -> 20
+ 20 '<'      retract
+ 20 accept "12" at 1:1
+  0 '<'      -> 1
+  1 'a'      -> 4
+  4 'a'      retract
+  4 accept "<" at 1:3
+  9 'a'      -> 10
+ 10 EOF      -> 11
+ 11 EOF      retract
+ 11 accept "a" at 1:4
+`[1:]
+	if got := traceOutput(t, "12<a", TraceText); got != want {
+		t.Errorf("trace of %q:\n%s\nwant:\n%s", "12<a", got, want)
+	}
+}
+
+func TestTraceJSON(t *testing.T) {
+	want := `
+{"event":"step","state":12,"char":"1","next":13}
+{"event":"step","state":13,"char":"2","next":13}
+{"event":"step","state":13,"char":"\u003c","next":20}
+{"event":"retract","state":20,"char":"\u003c"}
+{"event":"accept","state":20,"lexeme":"12","line":1,"column":1,"offset":0}
+{"event":"step","state":0,"char":"\u003c","next":1}
+{"event":"step","state":1,"char":"a","next":4}
+{"event":"retract","state":4,"char":"a"}
+{"event":"accept","state":4,"lexeme":"\u003c","line":1,"column":3,"offset":2}
+{"event":"step","state":9,"char":"a","next":10}
+{"event":"step","state":10,"char":null,"next":11}
+{"event":"retract","state":11,"char":null}
+{"event":"accept","state":11,"lexeme":"a","line":1,"column":4,"offset":3}
+`[1:]
+	if got := traceOutput(t, "12<a", TraceJSON); got != want {
+		t.Errorf("trace of %q:\n%s\nwant:\n%s", "12<a", got, want)
+	}
+}
+
+// TestTraceJSONEvents decodes the JSON trace of inputs over several lines
+// and checks it line by line against the events themselves.
+func TestTraceJSONEvents(t *testing.T) {
+	for _, src := range []string{
+		"x = 12.5e3\nif y >= 'c' {\n\t/* z */ s = \"größe\"\n}",
+		"a\n\nb",
+		"",
+	} {
+		events := traceEvents(t, src, 0)
+		sc := bufio.NewScanner(strings.NewReader(traceOutput(t, src, TraceJSON)))
+		i := 0
+		for ; sc.Scan(); i++ {
+			if i == len(events) {
+				t.Fatalf("%q: more lines than the %d events", src, len(events))
+			}
+			var line struct {
+				Event  string
+				State  int
+				Char   *string
+				Next   *int
+				Lexeme *string
+				Line   int
+				Column int
+				Offset int
+			}
+			if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+				t.Fatalf("%q: line %d: %v", src, i+1, err)
+			}
+			e := events[i]
+			ok := line.Event == e.Kind.String() && line.State == e.State
+			switch e.Kind {
+			case TraceStep, TraceRetract:
+				if e.Char == EOF {
+					ok = ok && line.Char == nil
+				} else {
+					ok = ok && line.Char != nil && *line.Char == string(e.Char)
+				}
+				ok = ok && line.Lexeme == nil && (line.Next != nil) == (e.Kind == TraceStep)
+				if e.Kind == TraceStep {
+					ok = ok && *line.Next == e.Next
+				}
+			case TraceAccept:
+				ok = ok && line.Char == nil && line.Next == nil &&
+					line.Lexeme != nil && *line.Lexeme == e.Lexeme &&
+					line.Line == e.Pos.Line && line.Column == e.Pos.Column && line.Offset == e.Pos.Offset
+			}
+			if !ok {
+				t.Errorf("%q: line %d is %s for %v", src, i+1, sc.Bytes(), e)
+			}
+		}
+		if i != len(events) {
+			t.Errorf("%q: %d lines for %d events", src, i, len(events))
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter3/3-4/lexer"
+)
+
+//go:generate sh -c "go run . -dot > diagrams.dot"
+
+func main() {
+	dot := flag.Bool("dot", false, "write the transition diagrams in the DOT language instead")
+	keywordFile := flag.String("keywords", "", "read the keywords from a keyword table")
+	ignoreCase := flag.Bool("ignorecase", false, "match keywords whatever the case of their letters")
+	trace := flag.String("trace", "", "write every step of the transition diagrams to the standard error, as text or json")
+	search := flag.Bool("search", false, "report every occurrence of the keywords in the input instead, even inside words")
+	flag.Parse()
+	if *dot {
+		for i := range lexer.Diagrams {
+			if err := lexer.Diagrams[i].WriteDOT(os.Stdout); err != nil {
+				log.Fatalln("main():", err)
+			}
+		}
+		return
+	}
+
+	// the input is program.data, or the file named by the argument, or the
+	// standard input for -, which may well be a pipe
+	file := os.Stdin
+	if flag.Arg(0) != "-" {
+		dir, err := os.Getwd()
+		if err != nil {
+			log.Fatalln("main():", err)
+		}
+	//	fmt.Println(dir)
+		name := dir + "/" + "program.data"
+		if flag.NArg() > 0 {
+			name = flag.Arg(0)
+		}
+		file, err = os.Open(name)
+		if err != nil {
+			log.Fatalln("main():", err)
+		}
+		defer file.Close()
+	}
+	keywords := lexer.DefaultKeywords
+	if *keywordFile != "" {
+		kf, err := os.Open(*keywordFile)
+		if err != nil {
+			log.Fatalln("main():", err)
+		}
+		keywords, err = lexer.LoadKeywords(kf)
+		kf.Close()
+		if err != nil {
+			log.Fatalln("main():", err)
+		}
+	}
+	if *search {
+		text, err := ioutil.ReadAll(file)
+		if err != nil {
+			log.Fatalln("main():", err)
+		}
+		for _, m := range lexer.NewMatcher(keywords).FindAll(string(text)) {
+			fmt.Println(m.Offset, m.Lexeme, m.Keyword)
+		}
+		return
+	}
+	lex, err := lexer.NewLexerKeywords(4096, file, keywords)
+	if err != nil {
+		log.Fatalln("main():", err)
+	}
+	lex.Mode = lexer.RecoverErrors
+	switch *trace {
+	case "":
+	case "text":
+		lex.Trace = lexer.TraceText(os.Stderr)
+	case "json":
+		lex.Trace = lexer.TraceJSON(os.Stderr)
+	default:
+		log.Fatalln("main(): -trace must be text or json, not", *trace)
+	}
+	if *ignoreCase {
+		lex.Mode |= lexer.IgnoreKeywordCase
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for item := range lexer.Stream(ctx, lex, 64) {
+		if item.Err != nil {
+			log.Fatalln("main():", item.Err)
+		}
+		tok := item.Token
+		switch t := tok.(type) {
+		case *lexer.Id:
+			fmt.Println(tok.Pos(), tok.End(), t.Lexeme, t.Keyword, reflect.TypeOf(tok))
+		case *lexer.Number:
+			switch t.Kind {
+			case lexer.IntValue:
+				fmt.Println(tok.Pos(), tok.End(), t.Lexeme, t.Int, reflect.TypeOf(tok))
+			case lexer.FloatValue:
+				fmt.Println(tok.Pos(), tok.End(), t.Lexeme, t.Float, reflect.TypeOf(tok))
+			case lexer.BigIntValue:
+				fmt.Println(tok.Pos(), tok.End(), t.Lexeme, t.BigInt, reflect.TypeOf(tok))
+			case lexer.BigFloatValue:
+				fmt.Println(tok.Pos(), tok.End(), t.Lexeme, t.BigFloat, reflect.TypeOf(tok))
+			}
+		case *lexer.Relop:
+			fmt.Println(tok.Pos(), tok.End(), t.Lexeme, t.Attribute, reflect.TypeOf(tok))
+		case *lexer.Operator:
+			fmt.Println(tok.Pos(), tok.End(), t.Lexeme, t.Attribute, reflect.TypeOf(tok))
+		case *lexer.Delimiter:
+			fmt.Println(tok.Pos(), tok.End(), t.Lexeme, t.Attribute, reflect.TypeOf(tok))
+		case *lexer.String:
+			fmt.Println(tok.Pos(), tok.End(), t.Lexeme, strconv.Quote(t.Value), reflect.TypeOf(tok))
+		case *lexer.Char:
+			fmt.Println(tok.Pos(), tok.End(), t.Lexeme, strconv.QuoteRune(t.Value), reflect.TypeOf(tok))
+		case *lexer.Comment:
+			fmt.Println(tok.Pos(), tok.End(), t.Lexeme, reflect.TypeOf(tok))
+		case *lexer.Invalid:
+			fmt.Println(tok.Pos(), tok.End(), strconv.Quote(t.Lexeme), reflect.TypeOf(tok))
+		}
+	}
+	for _, d := range lex.Diagnostics() {
+		log.Println("main():", d.Err)
+	}
+}
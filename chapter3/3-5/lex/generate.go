@@ -0,0 +1,273 @@
+package lex
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter3/3-7/regex"
+)
+
+// maxInline is the largest number of ranges of characters tested inline by
+// the generated code; larger classes are looked up in a table.
+const maxInline = 4
+
+// Generate returns the source of the scanner for spec. The patterns are
+// combined into one NFA as in Fig. 3.52, which is turned into a minimal DFA;
+// every state of the DFA becomes a case of a switch, as in the hand-written
+// diagrams of section 3.4.
+func Generate(spec *Spec) ([]byte, error) {
+	nfas := make([]*regex.NFA, len(spec.Rules))
+	for i, rule := range spec.Rules {
+		nfa, err := regex.Compile(rule.Expr)
+		if err != nil {
+			return nil, &SpecError{spec.Name, rule.Line, err.Error()}
+		}
+		nfas[i] = nfa
+	}
+	dfa := regex.Combine(nfas...).DFA().Minimize()
+	if p := dfa.Accept[dfa.Start]; p >= 0 {
+		return nil, &SpecError{spec.Name, spec.Rules[p].Line, "pattern matches the empty string"}
+	}
+	matched := make([]bool, len(spec.Rules))
+	for _, p := range dfa.Accept {
+		if p >= 0 {
+			matched[p] = true
+		}
+	}
+	for i, rule := range spec.Rules {
+		if !matched[i] {
+			return nil, &SpecError{spec.Name, rule.Line, "rule can never be matched"}
+		}
+	}
+
+	g := &generator{spec: spec, dfa: dfa}
+	g.printf("// Code generated by lex from %s. DO NOT EDIT.\n\n", spec.Name)
+	g.printf("package lexer\n\n")
+	body := g.body()
+	if len(g.tables) > 0 {
+		g.printf("import (\n\"io\"\n\"sort\"\n)\n\n")
+	} else {
+		g.printf("import \"io\"\n\n")
+	}
+	g.printf("%s\n", spec.Decls)
+	g.buf.Write(body)
+	g.tablesAndAux()
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("lex: %s: generated code: %v", spec.Name, err)
+	}
+	return src, nil
+}
+
+type generator struct {
+	spec   *Spec
+	dfa    *regex.DFA
+	buf    bytes.Buffer
+	tables [][]regex.Range // the classes looked up by in
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// body returns the type of the scanner and its methods.
+func (g *generator) body() []byte {
+	var buf bytes.Buffer
+	printf := func(format string, args ...interface{}) {
+		fmt.Fprintf(&buf, format, args...)
+	}
+	name := g.spec.Scanner
+	printf("// %s is the scanner described by %s.\n", name, g.spec.Name)
+	printf("type %s struct {\n", name)
+	printf("df *DoubleBuffer\n")
+	printf("diagnostics []Diagnostic\n")
+	printf("}\n\n")
+
+	printf("func New%s(bufSize int, inputSrc io.Reader) (*%s, error) {\n", name, name)
+	printf("df, err := newDoubleBuffer(bufSize, inputSrc)\n")
+	printf("if err != nil {\nreturn nil, err\n}\n")
+	printf("return &%s{df: df}, nil\n", name)
+	printf("}\n\n")
+
+	printf("// NextToken returns the next token of the input, or nil and io.EOF once\n")
+	printf("// the input is exhausted. It runs the action of the rule that matches the\n")
+	printf("// longest lexeme, the earliest rule among those that match it; when no\n")
+	printf("// rule matches, one character is skipped and reported by an\n")
+	printf("// *InvalidCharError.\n")
+	printf("func (scanner *%s) NextToken() (Token, error) {\n", name)
+	printf("for {\n")
+	printf("rule := scanner.match()\n")
+	printf("if scanner.df.err != nil {\nreturn nil, scanner.df.err\n}\n")
+	printf("if rule < 0 {\n")
+	printf("ch, err := scanner.df.nextChar()\n")
+	printf("if err != nil {\nreturn nil, err\n}\n")
+	printf("_, sp := scanner.df.nextLexeme()\n")
+	printf("return nil, &InvalidCharError{ch, sp.begin}\n")
+	printf("}\n")
+	printf("lexeme, sp := scanner.df.nextLexeme()\n")
+	printf("_, _ = lexeme, sp // not every action needs them\n")
+	printf("switch rule {\n")
+	for i, rule := range g.spec.Rules {
+		printf("case %d: // line %d: %s\n", i, rule.Line, rule.Pattern)
+		if rule.Action != "" {
+			printf("%s\n", rule.Action)
+		}
+	}
+	printf("}\n")
+	printf("}\n")
+	printf("}\n\n")
+
+	printf("// Diagnostics returns the problems recorded by the actions through diagnose.\n")
+	printf("func (scanner *%s) Diagnostics() []Diagnostic {\nreturn scanner.diagnostics\n}\n\n", name)
+	printf("func (scanner *%s) diagnose(err error, pos Position) {\n", name)
+	printf("scanner.diagnostics = append(scanner.diagnostics, Diagnostic{pos, err})\n")
+	printf("}\n\n")
+
+	printf("// match runs the DFA of the rules from lexemeBegin and returns the rule\n")
+	printf("// accepting the longest lexeme, with forward retracted to its end, or -1.\n")
+	printf("func (scanner *%s) match() int {\n", name)
+	printf("rule, back := -1, 0 // back counts the characters read past the lexeme of rule\n")
+	printf("state := %d\n", g.dfa.Start)
+	printf("for state >= 0 {\n")
+	printf("switch state {\n")
+	for s, row := range g.dfa.Trans {
+		printf("case %d:\n", s)
+		if p := g.dfa.Accept[s]; p >= 0 {
+			printf("rule, back = %d, 0\n", p)
+		}
+		targets, classes := g.transitions(row)
+		if len(targets) == 0 {
+			printf("state = -1\n")
+			continue
+		}
+		printf("ch, _ := scanner.df.nextChar()\n")
+		printf("back++\n")
+		printf("switch {\n")
+		for i, t := range targets {
+			printf("case %s:\n", g.condition(classes[i]))
+			printf("state = %d\n", t)
+		}
+		printf("default:\nstate = -1\n")
+		printf("}\n")
+	}
+	printf("}\n")
+	printf("}\n")
+	printf("for ; back > 0; back-- {\n")
+	printf("scanner.df.backword()\n")
+	printf("}\n")
+	printf("return rule\n")
+	printf("}\n")
+	return buf.Bytes()
+}
+
+// transitions groups the ranges of characters on which the transitions of row
+// lead to each state.
+func (g *generator) transitions(row []int) (targets []int, classes [][]regex.Range) {
+	byTarget := map[int][]regex.Range{}
+	for a, t := range row {
+		if t < 0 {
+			continue
+		}
+		r := g.dfa.Alphabet[a]
+		ranges := byTarget[t]
+		if n := len(ranges); n > 0 && ranges[n-1].Hi+1 == r.Lo {
+			ranges[n-1].Hi = r.Hi
+		} else {
+			byTarget[t] = append(ranges, r)
+		}
+	}
+	for t := range byTarget {
+		targets = append(targets, t)
+	}
+	sort.Ints(targets)
+	for _, t := range targets {
+		classes = append(classes, byTarget[t])
+	}
+	return targets, classes
+}
+
+// condition returns the Go expression testing that ch is in ranges.
+func (g *generator) condition(ranges []regex.Range) string {
+	if len(ranges) > maxInline {
+		i := 0
+		for ; i < len(g.tables); i++ {
+			if fmt.Sprint(g.tables[i]) == fmt.Sprint(ranges) {
+				break
+			}
+		}
+		if i == len(g.tables) {
+			g.tables = append(g.tables, ranges)
+		}
+		return fmt.Sprintf("scanner.in(ch, %s[%d])", g.tableName(), i)
+	}
+	var terms []string
+	for _, r := range ranges {
+		if r.Lo == r.Hi {
+			terms = append(terms, "ch == "+strconv.QuoteRune(r.Lo))
+		} else {
+			terms = append(terms, strconv.QuoteRune(r.Lo)+" <= ch && ch <= "+strconv.QuoteRune(r.Hi))
+		}
+	}
+	return strings.Join(terms, " || ")
+}
+
+func (g *generator) tableName() string {
+	name := []rune(g.spec.Scanner)
+	name[0] = unicode.ToLower(name[0])
+	return string(name) + "Classes"
+}
+
+// tablesAndAux writes the tables of the classes used by in, and in itself,
+// and the auxiliary functions.
+func (g *generator) tablesAndAux() {
+	if len(g.tables) > 0 {
+		g.printf("\n// %s holds the bounds of the ranges of the larger classes of\n", g.tableName())
+		g.printf("// characters, in pairs.\n")
+		g.printf("var %s = [][]rune{\n", g.tableName())
+		for _, ranges := range g.tables {
+			g.printf("{")
+			for i, r := range ranges {
+				if i%8 == 0 {
+					g.printf("\n")
+				}
+				g.printf("%#x, %#x, ", r.Lo, r.Hi)
+			}
+			g.printf("\n},\n")
+		}
+		g.printf("}\n\n")
+		g.printf("// in reports whether ch is in one of the ranges bounded by pairs of ranges.\n")
+		g.printf("func (*%s) in(ch rune, ranges []rune) bool {\n", g.spec.Scanner)
+		g.printf("i := sort.Search(len(ranges)/2, func(i int) bool { return ranges[2*i+1] >= ch })\n")
+		g.printf("return i < len(ranges)/2 && ranges[2*i] <= ch\n")
+		g.printf("}\n")
+	}
+	if g.spec.RuleVar != "" {
+		g.printf("\n// %s are the translation rules of %s, for NewTable.\n", g.spec.RuleVar, g.spec.Name)
+		g.printf("var %s = []Rule{\n", g.spec.RuleVar)
+		for _, rule := range g.spec.Rules {
+			kind, attr := rule.Kind, rule.attr()
+			if kind == "" {
+				kind, attr = "SkipKind", "0"
+			}
+			g.printf("{%s, %s, %s}, // line %d: %s\n", quote(rule.Expr), kind, attr, rule.Line, rule.Pattern)
+		}
+		g.printf("}\n")
+	}
+	if g.spec.Aux != "" {
+		g.printf("\n%s\n", g.spec.Aux)
+	}
+}
+
+// quote returns s as a Go string literal, a raw one if it can be.
+func quote(s string) string {
+	if strings.ContainsAny(s, "`\r") || !utf8.ValidString(s) {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
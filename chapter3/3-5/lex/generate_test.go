@@ -0,0 +1,93 @@
+package lex
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// TestGenerateTokens checks that spec_scanner.go is what tokens.l generates.
+func TestGenerateTokens(t *testing.T) {
+	src, err := ioutil.ReadFile("../../3-4/lexer/tokens.l")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile("../../3-4/lexer/spec_scanner.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, err := Parse("tokens.l", string(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := Generate(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(code, want) {
+		t.Errorf("spec_scanner.go is not the scanner generated from tokens.l; run go generate in chapter3/3-4/lexer")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	spec, err := Parse("toy.l", "%scanner Toy\n%rules ToyRules\n%%\n[a-cegikmo]+  %token IdKind REST\n[0-9]+  %token NumberKind 10\n\" \"  {}\n%%\n// end\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := Generate(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "toy.go", code, 0)
+	if err != nil {
+		t.Fatalf("generated code: %v\n%s", err, code)
+	}
+	if f.Name.Name != "lexer" {
+		t.Errorf("generated package %s, want lexer", f.Name.Name)
+	}
+	for _, want := range []string{
+		"// Code generated by lex from toy.l. DO NOT EDIT.",
+		"type Toy struct {",
+		"func NewToy(bufSize int, inputSrc io.Reader) (*Toy, error) {",
+		"func (scanner *Toy) NextToken() (Token, error) {",
+		"return scanner.token(IdKind, int(REST), lexeme, sp)",
+		"case '0' <= ch && ch <= '9':",
+		"scanner.in(ch, toyClasses[0])", // the five ranges of [a-cegikmo]
+		"var ToyRules = []Rule{",
+		"{`[a-cegikmo]+`, IdKind, int(REST)}, // line 4: [a-cegikmo]+",
+		"{` `, SkipKind, 0},",
+		"// end",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Errorf("generated code lacks %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		src, err string
+	}{
+		{"%%\na*  {}\n", "lex: x.l:2: pattern matches the empty string"},
+		{"%%\na  {}\nb|a  {}\n", ""},
+		{"%%\na|b  {}\na  {}\n", "lex: x.l:3: rule can never be matched"},
+		{"%%\n(a  {}\n", `lex: x.l:2: regex: "(a" at offset 0: missing )`},
+		{"%%\na  return nil, nil +\n", "lex: x.l: generated code:"},
+	}
+	for _, test := range tests {
+		spec, err := Parse("x.l", test.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = Generate(spec)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("Generate(%q): %v", test.src, err)
+		case test.err != "" && (err == nil || !strings.HasPrefix(err.Error(), test.err)):
+			t.Errorf("Generate(%q) error = %v, want %s", test.src, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+// Package lex is a scanner generator in the manner of Lex (section 3.5). It
+// reads a specification made of declarations, translation rules and auxiliary
+// functions,
+//
+//	declarations
+//	%%
+//	translation rules
+//	%%
+//	auxiliary functions
+//
+// and emits the scanner it describes as Go code for package lexer of section
+// 3.4, reading its input through a DoubleBuffer.
+//
+// The declarations are regular definitions, one a line, as in
+//
+//	digit    [0-9]
+//	digits   {digit}+
+//
+// Go code between lines %{ and %}, which is copied to the scanner after its
+// imports, and the line
+//
+//	%scanner Name
+//
+// naming the type of the scanner, SpecScanner if it is left out, and the line
+//
+//	%rules Name
+//
+// naming a variable of type []Rule of package lexer that lists the rules for
+// a TableLexer, as described below. Blank lines and lines starting with //
+// are ignored.
+//
+// A translation rule is a pattern followed by an action: Go statements
+// either on the rest of the line or, between braces, on as many lines as they
+// need. An action of | is that of the next rule. Patterns are regular
+// expressions in the syntax of package regex, in which {name} stands for a
+// definition and a string in double quotes for its characters, so that
+// "/*" is the same as /\*. The action of the rule that matches the longest
+// lexeme runs, the earliest rule winning among those that match it; it sees
+// the lexeme in lexeme and its span in sp, and returns a Token and an error
+// or, if it does not return, drops the lexeme as is done for white space.
+//
+// An action may instead name the token that the rule makes, as in
+//
+//	"<="      %token RelopKind LE
+//
+// for the action
+//
+//	return scanner.token(RelopKind, int(LE), lexeme, sp)
+//
+// where the scanner is left to define token in the auxiliary functions. With
+// %rules, every action must be such a %token or empty, and the variable lists
+// the Expr of every rule with its TokenKind and Attr, SkipKind for an empty
+// action, so that a TableLexer built from it makes the same tokens.
+//
+// The auxiliary functions are Go code copied to the end of the scanner.
+package lex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Spec is a parsed specification.
+type Spec struct {
+	Name    string            // file name of the specification
+	Scanner string            // type name of the scanner
+	RuleVar string            // name of the variable listing the rules, if any
+	Decls   string            // code between %{ and %}
+	Defs    map[string]string // regular definitions, expanded
+	Rules   []Rule
+	Aux     string // auxiliary functions
+}
+
+// Rule is a translation rule; Expr is its Pattern with definitions expanded
+// and quoted strings escaped, ready for regex.Compile. Kind and Attr are the
+// Go expressions of a %token action.
+type Rule struct {
+	Pattern string
+	Expr    string
+	Action  string
+	Kind    string
+	Attr    string
+	Line    int
+}
+
+// SpecError reports a problem with line Line of the specification in file
+// Name.
+type SpecError struct {
+	Name string
+	Line int
+	Msg  string
+}
+
+func (e *SpecError) Error() string {
+	return fmt.Sprintf("lex: %s:%d: %s", e.Name, e.Line, e.Msg)
+}
+
+// Parse parses the specification src, read from the file name.
+func Parse(name string, src string) (*Spec, error) {
+	spec := &Spec{Name: name, Scanner: "SpecScanner", Defs: map[string]string{}}
+	lines := strings.Split(src, "\n")
+	i := 0
+	errorf := func(format string, args ...interface{}) error {
+		return &SpecError{name, i + 1, fmt.Sprintf(format, args...)}
+	}
+
+	// declarations
+	for ; i < len(lines) && strings.TrimSpace(lines[i]) != "%%"; i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case line == "" || strings.HasPrefix(line, "//"):
+		case line == "%{":
+			start := i
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "%}"; i++ {
+				spec.Decls += lines[i] + "\n"
+			}
+			if i == len(lines) {
+				i = start
+				return nil, errorf("missing %%}")
+			}
+		case strings.HasPrefix(line, "%scanner"):
+			spec.Scanner = strings.TrimSpace(line[len("%scanner"):])
+			if !isIdent(spec.Scanner) {
+				return nil, errorf("invalid scanner name %q", spec.Scanner)
+			}
+		case strings.HasPrefix(line, "%rules"):
+			spec.RuleVar = strings.TrimSpace(line[len("%rules"):])
+			if !isIdent(spec.RuleVar) {
+				return nil, errorf("invalid rules name %q", spec.RuleVar)
+			}
+		default:
+			blank := strings.IndexAny(line, " \t")
+			if blank < 0 || !isIdent(line[:blank]) {
+				return nil, errorf("invalid definition %q", line)
+			}
+			expr, err := spec.expand(strings.TrimSpace(line[blank:]))
+			if err != nil {
+				return nil, errorf("%v", err)
+			}
+			spec.Defs[line[:blank]] = expr
+		}
+	}
+	if i == len(lines) {
+		return nil, errorf("missing %%%%")
+	}
+
+	// translation rules
+	for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "%%"; i++ {
+		line := lines[i]
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		pattern, action := splitRule(line)
+		expr, err := spec.expand(pattern)
+		if err != nil {
+			return nil, errorf("%v", err)
+		}
+		rule := Rule{Pattern: pattern, Expr: expr, Line: i + 1}
+		if strings.HasPrefix(action, "{") {
+			start := i
+			text := action
+			for depth(text) > 0 {
+				if i++; i == len(lines) {
+					i = start
+					return nil, errorf("missing } in action")
+				}
+				text += "\n" + lines[i]
+			}
+			action = strings.TrimSpace(text[1:strings.LastIndex(text, "}")])
+		}
+		if strings.HasPrefix(action, "%token") {
+			fields := strings.Fields(action[len("%token"):])
+			if len(fields) != 2 {
+				return nil, errorf("want a kind and an attribute after %%token, have %q", action)
+			}
+			rule.Kind, rule.Attr = fields[0], fields[1]
+			action = fmt.Sprintf("return scanner.token(%s, %s, lexeme, sp)", rule.Kind, rule.attr())
+		}
+		rule.Action = action
+		spec.Rules = append(spec.Rules, rule)
+	}
+
+	// an action of | is that of the next rule
+	for j := len(spec.Rules) - 1; j >= 0; j-- {
+		if spec.Rules[j].Action == "|" {
+			if j == len(spec.Rules)-1 {
+				return nil, &SpecError{name, spec.Rules[j].Line, "action | of the last rule"}
+			}
+			next := spec.Rules[j+1]
+			spec.Rules[j].Action, spec.Rules[j].Kind, spec.Rules[j].Attr = next.Action, next.Kind, next.Attr
+		}
+		if spec.RuleVar != "" && spec.Rules[j].Kind == "" && spec.Rules[j].Action != "" {
+			return nil, &SpecError{name, spec.Rules[j].Line, "action neither %token nor empty, with %rules"}
+		}
+	}
+	if len(spec.Rules) == 0 {
+		return nil, errorf("no translation rules")
+	}
+	if i < len(lines) {
+		spec.Aux = strings.Join(lines[i+1:], "\n")
+	}
+	return spec, nil
+}
+
+// attr returns the Attr of a %token action as an int expression.
+func (rule *Rule) attr() string {
+	if _, err := strconv.Atoi(rule.Attr); err == nil {
+		return rule.Attr
+	}
+	return "int(" + rule.Attr + ")"
+}
+
+func isIdent(s string) bool {
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// splitRule splits a rule at the first blank that is not in a character
+// class, in quotes or escaped.
+func splitRule(line string) (pattern, action string) {
+	inClass, inQuote := false, false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\':
+			i++
+		case inClass:
+			inClass = c != ']'
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '[':
+			inClass = true
+			if strings.HasPrefix(line[i+1:], "^") {
+				i++
+			}
+			if strings.HasPrefix(line[i+1:], "]") {
+				i++ // a ] first in a class stands for itself
+			}
+		case c == ' ' || c == '\t':
+			return line[:i], strings.TrimSpace(line[i:])
+		}
+	}
+	return line, ""
+}
+
+// depth returns the number of braces opened and not closed in the Go code
+// text, ignoring those in comments and literals.
+func depth(text string) int {
+	d := 0
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '{':
+			d++
+		case '}':
+			d--
+		case '"', '\'', '`':
+			for i++; i < len(text) && text[i] != c && text[i] != '\n'; i++ {
+				if text[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+		case '/':
+			if strings.HasPrefix(text[i:], "//") {
+				for i < len(text) && text[i] != '\n' {
+					i++
+				}
+			}
+		}
+	}
+	return d
+}
+
+// expand returns pattern with every {name} replaced by the definition of
+// name in parentheses and every string in double quotes by its characters,
+// escaped where they are operators of regex.
+func (spec *Spec) expand(pattern string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '\\':
+			b.WriteByte(c)
+			if i+1 < len(pattern) {
+				i++
+				b.WriteByte(pattern[i])
+			}
+		case '[':
+			// copy the class as it is
+			j := i + 1
+			if strings.HasPrefix(pattern[j:], "^") {
+				j++
+			}
+			if strings.HasPrefix(pattern[j:], "]") {
+				j++
+			}
+			for ; j < len(pattern) && pattern[j] != ']'; j++ {
+				if pattern[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(pattern) {
+				return "", fmt.Errorf("missing ] in %s", pattern)
+			}
+			b.WriteString(pattern[i : j+1])
+			i = j
+		case '"':
+			j := i + 1
+			for ; j < len(pattern) && pattern[j] != '"'; j++ {
+				if pattern[j] == '\\' && j+1 < len(pattern) {
+					// an escape sequence of regex, such as \n or \"
+					b.WriteString(pattern[j : j+2])
+					j++
+					continue
+				}
+				if strings.IndexByte(`.+*?()|[]{}^$`, pattern[j]) >= 0 {
+					b.WriteByte('\\')
+				}
+				b.WriteByte(pattern[j])
+			}
+			if j == len(pattern) {
+				return "", fmt.Errorf("missing \" in %s", pattern)
+			}
+			i = j
+		case '{':
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 || !isIdent(pattern[i+1:i+end]) {
+				b.WriteString(`\{`)
+				break
+			}
+			name := pattern[i+1 : i+end]
+			def, ok := spec.Defs[name]
+			if !ok {
+				return "", fmt.Errorf("undefined definition {%s}", name)
+			}
+			b.WriteString("(" + def + ")")
+			i += end
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
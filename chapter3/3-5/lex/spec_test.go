@@ -0,0 +1,163 @@
+package lex
+
+import (
+	"reflect"
+	"testing"
+)
+
+const toySpec = `// a toy scanner
+%scanner Toy
+%{
+const x = 1
+%}
+digit [0-9]
+num   {digit}+
+%%
+
+{num}     %token NumberKind 10
+"+"|"*"   |
+[-/]      %token OperatorKind PLUS
+[ \t]+    {}
+"{"  {
+	if true {
+		return nil, nil
+	}
+}
+%%
+func aux() {}
+`
+
+func TestParse(t *testing.T) {
+	spec, err := Parse("toy.l", toySpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := "return scanner.token(OperatorKind, int(PLUS), lexeme, sp)"
+	want := &Spec{
+		Name:    "toy.l",
+		Scanner: "Toy",
+		Decls:   "const x = 1\n",
+		Defs:    map[string]string{"digit": "[0-9]", "num": "([0-9])+"},
+		Rules: []Rule{
+			{`{num}`, `(([0-9])+)`, "return scanner.token(NumberKind, 10, lexeme, sp)", "NumberKind", "10", 10},
+			{`"+"|"*"`, `\+|\*`, op, "OperatorKind", "PLUS", 11},
+			{`[-/]`, `[-/]`, op, "OperatorKind", "PLUS", 12},
+			{`[ \t]+`, `[ \t]+`, "", "", "", 13},
+			{`"{"`, `\{`, "if true {\n\t\treturn nil, nil\n\t}", "", "", 14},
+		},
+		Aux: "func aux() {}\n",
+	}
+	if !reflect.DeepEqual(spec, want) {
+		t.Errorf("Parse() =\n%#v\nwant\n%#v", spec, want)
+	}
+
+	spec, err = Parse("rules.l", "%rules R\n%%\na  %token IdKind IF\nb  {}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.RuleVar != "R" || spec.Scanner != "SpecScanner" || spec.Aux != "" {
+		t.Errorf("Parse() = %#v, want RuleVar R and Scanner SpecScanner", spec)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src, err string
+	}{
+		{"digit [0-9]", "lex: x.l:2: missing %%"},
+		{"%{\nx\n", "lex: x.l:1: missing %}"},
+		{"%scanner 9x\n%%\na\n", `lex: x.l:1: invalid scanner name "9x"`},
+		{"%rules\n%%\na\n", `lex: x.l:1: invalid rules name ""`},
+		{"\n1abc [a]\n%%\na\n", `lex: x.l:2: invalid definition "1abc [a]"`},
+		{"d {e}\n%%\na\n", "lex: x.l:1: undefined definition {e}"},
+		{"%%\n[ab  x\n", "lex: x.l:2: missing ] in [ab  x"},
+		{"%%\n\"ab x\n", `lex: x.l:2: missing " in "ab x`},
+		{"%%\na {\n  x\n", "lex: x.l:2: missing } in action"},
+		{"%%\na %token X\n", `lex: x.l:2: want a kind and an attribute after %token, have "%token X"`},
+		{"%%\na {}\nb |\n", "lex: x.l:3: action | of the last rule"},
+		{"%rules R\n%%\na x()\n", "lex: x.l:3: action neither %token nor empty, with %rules"},
+		{"%%\n%%\n", "lex: x.l:2: no translation rules"},
+	}
+	for _, test := range tests {
+		spec, err := Parse("x.l", test.src)
+		if err == nil {
+			t.Errorf("Parse(%q) = %#v, want error %s", test.src, spec, test.err)
+		} else if err.Error() != test.err {
+			t.Errorf("Parse(%q) error = %s, want %s", test.src, err, test.err)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	spec := &Spec{Defs: map[string]string{"d": "[0-9]"}}
+	tests := []struct {
+		pattern, expr, err string
+	}{
+		{`"a.b"`, `a\.b`, ""},
+		{`"(*)"+`, `\(\*\)+`, ""},
+		{`"\n\""`, `\n\"`, ""},
+		{`{d}x`, `([0-9])x`, ""},
+		{`{d}{d}*`, `([0-9])([0-9])*`, ""},
+		{`a{2}`, `a\{2}`, ""},
+		{`[{d}]`, `[{d}]`, ""},
+		{`[]"]`, `[]"]`, ""},
+		{`[^]"]"x"`, `[^]"]x`, ""},
+		{`\{d}`, `\{d}`, ""},
+		{`{nope}`, "", "undefined definition {nope}"},
+		{`"open`, "", `missing " in "open`},
+		{`[open`, "", "missing ] in [open"},
+	}
+	for _, test := range tests {
+		expr, err := spec.expand(test.pattern)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("expand(%s) = %s, %v, want error %s", test.pattern, expr, err, test.err)
+			}
+			continue
+		}
+		if err != nil || expr != test.expr {
+			t.Errorf("expand(%s) = %s, %v, want %s", test.pattern, expr, err, test.expr)
+		}
+	}
+}
+
+func TestSplitRule(t *testing.T) {
+	tests := []struct {
+		line, pattern, action string
+	}{
+		{`a b`, `a`, `b`},
+		{`abc`, `abc`, ``},
+		{"a\t{ x }  ", `a`, `{ x }`},
+		{`[ ] x`, `[ ]`, `x`},
+		{`[] ]+ y`, `[] ]+`, `y`},
+		{`[^] ] z`, `[^] ]`, `z`},
+		{`" " w`, `" "`, `w`},
+		{`"\" " v`, `"\" "`, `v`},
+		{`\  u`, `\ `, `u`},
+	}
+	for _, test := range tests {
+		if pattern, action := splitRule(test.line); pattern != test.pattern || action != test.action {
+			t.Errorf("splitRule(%q) = %q, %q, want %q, %q", test.line, pattern, action, test.pattern, test.action)
+		}
+	}
+}
+
+func TestDepth(t *testing.T) {
+	tests := []struct {
+		text  string
+		depth int
+	}{
+		{"{", 1},
+		{"{}", 0},
+		{"{ {", 2},
+		{`{ "{" '{' ` + "`{`" + ` }`, 0},
+		{`{ "\"{" }`, 0},
+		{"{ // }\n", 1},
+		{"{ // }\n}", 0},
+	}
+	for _, test := range tests {
+		if d := depth(test.text); d != test.depth {
+			t.Errorf("depth(%q) = %d, want %d", test.text, d, test.depth)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter3/3-5/lex"
+)
+
+// lex reads a specification and writes the scanner it describes:
+//
+//	go run . [-o scanner.go] spec.l
+//
+// The output file defaults to the name of the specification with .go in
+// place of its extension.
+func main() {
+	out := flag.String("o", "", "output file")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatalln("main(): usage: lex [-o scanner.go] spec.l")
+	}
+	name := flag.Arg(0)
+	src, err := ioutil.ReadFile(name)
+	if err != nil {
+		log.Fatalln("main():", err)
+	}
+	spec, err := lex.Parse(filepath.Base(name), string(src))
+	if err != nil {
+		log.Fatalln("main():", err)
+	}
+	code, err := lex.Generate(spec)
+	if err != nil {
+		log.Fatalln("main():", err)
+	}
+	if *out == "" {
+		*out = strings.TrimSuffix(name, filepath.Ext(name)) + ".go"
+	}
+	if err := ioutil.WriteFile(*out, code, 0644); err != nil {
+		log.Fatalln("main():", err)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter3/3-4/lexer"
+	"github.com/jmptrader/compilers-principles-techniques-tools/chapter3/3-7/regex"
+)
+
+// kinds are the kinds of the DefaultRules that match the lexemes of each
+// type of token that the transition diagrams of section 3.4 recognize;
+// comments and white space are both lexemes that the rules skip.
+var kinds = map[string]lexer.TokenKind{
+	"*lexer.Id":        lexer.IdKind,
+	"*lexer.Number":    lexer.NumberKind,
+	"*lexer.Relop":     lexer.RelopKind,
+	"*lexer.Operator":  lexer.OperatorKind,
+	"*lexer.Delimiter": lexer.DelimiterKind,
+	"*lexer.String":    lexer.StringKind,
+	"*lexer.Char":      lexer.CharKind,
+	"*lexer.Comment":   lexer.SkipKind,
+	"lexer.Ws":         lexer.SkipKind,
+}
+
+// patterns returns regular expressions for the tokens that the transition
+// diagrams of section 3.4 recognize, keyed by the type of those tokens: the
+// union of the patterns of the rules of their kind.
+func patterns(rules []lexer.Rule) map[string]string {
+	exprs := map[string][]string{}
+	for typ, kind := range kinds {
+		for _, rule := range rules {
+			if rule.Kind == kind {
+				exprs[typ] = append(exprs[typ], rule.Pattern)
+			}
+		}
+	}
+	patterns := map[string]string{}
+	for typ := range exprs {
+		patterns[typ] = strings.Join(exprs[typ], "|")
+	}
+	return patterns
+}
+
+const program = `/* compare the diagrams with the NFAs */
+if x1 >= 0x_FF then y = 1_000.25e-3; else z <> 0b1010
+while (größe != 0o17 && ok || !done) { n = n % 3 * 2 / 1 - 7 + 0; }
+s = "tab\t \"quoted\" \x41"; c = '\n'; d = 'é'; e = 'é' // end
+for [a, b]`
+
+// diagramStates are the numbers of states of the hand-written diagrams.
+var diagramStates = map[string]int{
+	"*lexer.Id":     3,  // 9-11
+	"*lexer.Number": 18, // 12-21 and 64-71
+	"*lexer.Relop":  9,  // 0-8
+}
+
+func main() {
+	dot := flag.String("dot", "", "write the NFA, DFA and minimal DFA of a regular expression in the DOT language instead")
+	flag.Parse()
+	if *dot != "" {
+		nfa, err := regex.Compile(*dot)
+		if err != nil {
+			log.Fatalln("main():", err)
+		}
+		dfa := nfa.DFA()
+		for _, err := range []error{
+			nfa.WriteDOT(os.Stdout, "NFA"),
+			dfa.WriteDOT(os.Stdout, "DFA"),
+			dfa.Minimize().WriteDOT(os.Stdout, "minimal DFA"),
+		} {
+			if err != nil {
+				log.Fatalln("main():", err)
+			}
+		}
+		return
+	}
+
+	patterns := patterns(lexer.DefaultRules)
+	nfas := make(map[string]*regex.NFA)
+	dfas := make(map[string]*regex.DFA)
+	var types, exprs []string
+	var trees []*regex.Node
+	for typ := range patterns {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	fmt.Printf("%-16s %5s %5s %6s %5s %8s\n", "token", "NFA", "DFA", "direct", "min", "diagram")
+	for _, typ := range types {
+		expr := patterns[typ]
+		tree, err := regex.Parse(expr)
+		if err != nil {
+			log.Fatalln("main():", err)
+		}
+		nfas[typ] = regex.Thompson(tree)
+		dfa := nfas[typ].DFA()
+		direct := regex.Direct(tree)
+		dfas[typ] = dfa.Minimize()
+		if !regex.Equivalent(dfa, dfas[typ]) {
+			log.Fatalln("main(): minimizing changed the language of", expr)
+		}
+		if !regex.Equivalent(dfa, direct) {
+			log.Fatalln("main(): the subset and the direct construction differ for", expr)
+		}
+		diagram := "-"
+		if n, ok := diagramStates[typ]; ok {
+			diagram = strconv.Itoa(n)
+		}
+		fmt.Printf("%-16s %5d %5d %6d %5d %8s\n", typ, nfas[typ].NumStates(), dfa.NumStates(), direct.NumStates(), dfas[typ].NumStates(), diagram)
+		exprs = append(exprs, expr)
+		trees = append(trees, tree)
+	}
+	var all []*regex.NFA
+	for _, typ := range types {
+		all = append(all, nfas[typ])
+	}
+	if !regex.Equivalent(regex.Combine(all...).DFA(), regex.Direct(trees...)) {
+		log.Fatalln("main(): the subset and the direct construction differ for", strings.Join(exprs, " and "))
+	}
+
+	lex, err := lexer.NewLexer(4096, strings.NewReader(program))
+	if err != nil {
+		log.Fatalln("main():", err)
+	}
+	lex.Mode = lexer.ScanComments
+	failed := 0
+	for {
+		tok, err := lex.NextToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalln("main():", err)
+		}
+		typ := reflect.TypeOf(tok).String()
+		begin, end := tok.Pos().Offset, tok.End().Offset
+		n, _ := nfas[typ].Longest(program[begin:])
+		if n != end-begin {
+			failed++
+			fmt.Printf("%v: %s %q: the NFA for %s matches %d bytes\n", tok.Pos(), typ, program[begin:end], patterns[typ], n)
+		}
+		n, _ = dfas[typ].Longest(program[begin:])
+		if n != end-begin {
+			failed++
+			fmt.Printf("%v: %s %q: the minimal DFA for %s matches %d bytes\n", tok.Pos(), typ, program[begin:end], patterns[typ], n)
+		}
+	}
+	if failed > 0 {
+		log.Fatalf("main(): %d tokens differ", failed)
+	}
+	fmt.Println("every token agrees with the longest match of its NFA and minimal DFA")
+
+	nfa := regex.MustCompile(`(a|b)*abb`)
+	fmt.Printf("\nThompson NFA for (a|b)*abb, %d states:\n%v", len(nfa.States), nfa)
+	dfa := nfa.DFA()
+	fmt.Printf("\nDFA by the subset construction, %d states:\n%v", dfa.NumStates(), dfa)
+	fmt.Printf("\nminimal DFA, %d states:\n%v", dfa.Minimize().NumStates(), dfa.Minimize())
+	direct := regex.Direct(regex.MustParse(`(a|b)*abb`))
+	fmt.Printf("\nDFA by the direct construction, %d states:\n%v", direct.NumStates(), direct)
+}
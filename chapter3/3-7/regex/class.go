@@ -0,0 +1,117 @@
+package regex
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Range is the set of the characters Lo through Hi.
+type Range struct {
+	Lo, Hi rune
+}
+
+// Class is a set of characters, kept as sorted ranges that neither overlap
+// nor touch. A single character is a Class of one Range.
+type Class []Range
+
+// newClass returns the Class of the characters in any of ranges.
+func newClass(ranges ...Range) Class {
+	c := Class{}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Lo < ranges[j].Lo })
+	for _, r := range ranges {
+		if n := len(c); n > 0 && r.Lo <= c[n-1].Hi+1 {
+			if r.Hi > c[n-1].Hi {
+				c[n-1].Hi = r.Hi
+			}
+		} else {
+			c = append(c, r)
+		}
+	}
+	return c
+}
+
+// Contains reports whether r is in c.
+func (c Class) Contains(r rune) bool {
+	i := sort.Search(len(c), func(i int) bool { return c[i].Hi >= r })
+	return i < len(c) && c[i].Lo <= r
+}
+
+// negate returns the characters that are not in c.
+func (c Class) negate() Class {
+	n := Class{}
+	lo := rune(0)
+	for _, r := range c {
+		if r.Lo > lo {
+			n = append(n, Range{lo, r.Lo - 1})
+		}
+		lo = r.Hi + 1
+	}
+	if lo <= unicode.MaxRune {
+		n = append(n, Range{lo, unicode.MaxRune})
+	}
+	return n
+}
+
+// tableClass returns the characters of a unicode.RangeTable.
+func tableClass(t *unicode.RangeTable) Class {
+	var ranges []Range
+	for _, r := range t.R16 {
+		for lo := rune(r.Lo); lo <= rune(r.Hi); lo += rune(r.Stride) {
+			if r.Stride == 1 {
+				ranges = append(ranges, Range{lo, rune(r.Hi)})
+				break
+			}
+			ranges = append(ranges, Range{lo, lo})
+		}
+	}
+	for _, r := range t.R32 {
+		for lo := rune(r.Lo); lo <= rune(r.Hi); lo += rune(r.Stride) {
+			if r.Stride == 1 {
+				ranges = append(ranges, Range{lo, rune(r.Hi)})
+				break
+			}
+			ranges = append(ranges, Range{lo, lo})
+		}
+	}
+	return newClass(ranges...)
+}
+
+// String returns c in the syntax of Parse.
+func (c Class) String() string {
+	if len(c) == 1 && c[0].Lo == c[0].Hi {
+		return quote(c[0].Lo, false)
+	}
+	var b strings.Builder
+	b.WriteByte('[')
+	for _, r := range c {
+		b.WriteString(quote(r.Lo, true))
+		if r.Hi > r.Lo {
+			b.WriteByte('-')
+			b.WriteString(quote(r.Hi, true))
+		}
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// quote writes r so that Parse reads it back as the character r, inside a
+// bracketed class or out of one.
+func quote(r rune, inClass bool) string {
+	switch {
+	case r == '\n':
+		return `\n`
+	case r == '\t':
+		return `\t`
+	case r == '\r':
+		return `\r`
+	case inClass && strings.ContainsRune(`\]^-[`, r):
+		return `\` + string(r)
+	case !inClass && strings.ContainsRune(metachars, r):
+		return `\` + string(r)
+	case unicode.IsPrint(r):
+		return string(r)
+	}
+	return `\x{` + strconv.FormatInt(int64(r), 16) + `}`
+}
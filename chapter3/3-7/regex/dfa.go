@@ -0,0 +1,198 @@
+package regex
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DFA is a deterministic finite automaton. Its input symbols are not single
+// characters but the ranges of Alphabet, which are disjoint and chosen so
+// that no transition tells apart two characters of the same range.
+type DFA struct {
+	Alphabet []Range
+	Trans    [][]int // Trans[s][a] is the state entered from s on Alphabet[a], or -1
+	Accept   []int   // the pattern accepted in each state, or -1
+	Start    int
+}
+
+// NumStates returns the number of states of the NFA.
+func (nfa *NFA) NumStates() int {
+	return len(nfa.States)
+}
+
+// NumStates returns the number of states of the DFA; the dead state, entered
+// on the transitions that are -1, is not counted.
+func (d *DFA) NumStates() int {
+	return len(d.Trans)
+}
+
+// alphabet splits the characters on the edges of the NFA into the fewest
+// disjoint ranges such that the class of every edge is a union of them.
+func (nfa *NFA) alphabet() []Range {
+	var classes []Class
+	for _, s := range nfa.States {
+		for _, e := range s.Edges {
+			if e.Class != nil {
+				classes = append(classes, e.Class)
+			}
+		}
+	}
+	return splitRanges(classes)
+}
+
+// splitRanges returns the disjoint ranges that every character of classes
+// falls into, cutting the characters wherever a range of a class starts or
+// ends.
+func splitRanges(classes []Class) []Range {
+	cuts := map[rune]bool{}
+	for _, c := range classes {
+		for _, r := range c {
+			cuts[r.Lo] = true
+			cuts[r.Hi+1] = true
+		}
+	}
+	points := make([]rune, 0, len(cuts))
+	for p := range cuts {
+		points = append(points, p)
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i] < points[j] })
+	var ranges []Range
+	for i := 0; i+1 < len(points); i++ {
+		for _, c := range classes {
+			if c.Contains(points[i]) {
+				ranges = append(ranges, Range{points[i], points[i+1] - 1})
+				break
+			}
+		}
+	}
+	return ranges
+}
+
+// symbol returns the index of the range of Alphabet that holds r, or -1.
+func (d *DFA) symbol(r rune) int {
+	i := sort.Search(len(d.Alphabet), func(i int) bool { return d.Alphabet[i].Hi >= r })
+	if i < len(d.Alphabet) && d.Alphabet[i].Lo <= r {
+		return i
+	}
+	return -1
+}
+
+// step returns the state entered from s on r, or -1 for the dead state.
+func (d *DFA) step(s int, r rune) int {
+	if s < 0 {
+		return -1
+	}
+	a := d.symbol(r)
+	if a < 0 {
+		return -1
+	}
+	return d.Trans[s][a]
+}
+
+// DFA returns a DFA for the NFA by the subset construction, Algorithm 3.20.
+// A DFA state accepts the smallest pattern accepted by its NFA states, which
+// is how section 3.8.3 resolves conflicts between the patterns of a lexer.
+// The empty set of NFA states is left out: transitions into it are -1.
+func (nfa *NFA) DFA() *DFA {
+	d := &DFA{Alphabet: nfa.alphabet()}
+	dstates := map[string]int{}
+	var unmarked [][]int
+
+	add := func(set *stateSet) int {
+		states := append([]int(nil), set.states...)
+		sort.Ints(states)
+		key := setKey(states)
+		if s, ok := dstates[key]; ok {
+			return s
+		}
+		s := len(d.Trans)
+		dstates[key] = s
+		d.Trans = append(d.Trans, make([]int, len(d.Alphabet)))
+		d.Accept = append(d.Accept, nfa.accepted(set))
+		unmarked = append(unmarked, states)
+		return s
+	}
+
+	cur, next := newStateSet(len(nfa.States)), newStateSet(len(nfa.States))
+	nfa.addClosure(cur, nfa.Start)
+	d.Start = add(cur)
+	for t := 0; t < len(unmarked); t++ {
+		cur.clear()
+		for _, s := range unmarked[t] {
+			cur.alreadyOn[s] = true
+			cur.states = append(cur.states, s)
+		}
+		for a, r := range d.Alphabet {
+			nfa.step(cur, next, r.Lo)
+			if len(next.states) == 0 {
+				d.Trans[t][a] = -1
+			} else {
+				d.Trans[t][a] = add(next)
+			}
+		}
+	}
+	return d
+}
+
+func setKey(states []int) string {
+	var b strings.Builder
+	for _, s := range states {
+		b.WriteString(strconv.Itoa(s))
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// Match reports whether the DFA accepts the whole of s.
+func (d *DFA) Match(s string) bool {
+	n, _ := d.Longest(s)
+	return n == len(s)
+}
+
+// Longest returns the length in bytes of the longest prefix of s that the DFA
+// accepts, together with the pattern accepted there, or -1, -1 if no prefix
+// of s is accepted.
+func (d *DFA) Longest(s string) (n, pattern int) {
+	n, pattern = -1, -1
+	state := d.Start
+	for i := 0; ; {
+		if p := d.Accept[state]; p >= 0 {
+			n, pattern = i, p
+		}
+		if i == len(s) {
+			return n, pattern
+		}
+		r, w := utf8.DecodeRuneInString(s[i:])
+		if state = d.step(state, r); state < 0 {
+			return n, pattern
+		}
+		i += w
+	}
+}
+
+// String lists the states of the DFA and their transitions, one state a line;
+// transitions into the dead state are left out.
+func (d *DFA) String() string {
+	var b strings.Builder
+	for s, row := range d.Trans {
+		mark := ' '
+		if s == d.Start {
+			mark = '>'
+		}
+		fmt.Fprintf(&b, "%c%d", mark, s)
+		if d.Accept[s] >= 0 {
+			fmt.Fprintf(&b, " accept %d", d.Accept[s])
+		}
+		b.WriteByte(':')
+		for a, t := range row {
+			if t >= 0 {
+				fmt.Fprintf(&b, " %v->%d", Class{d.Alphabet[a]}, t)
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package regex
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMinimizeBook checks the DFA of Fig. 3.36 for (a|b)*abb, with its five
+// states A to E, and the four states of its minimal DFA, in which A and C
+// are the same state (Example 3.40).
+func TestMinimizeBook(t *testing.T) {
+	dfa := MustCompile(`(a|b)*abb`).DFA()
+	if n := dfa.NumStates(); n != 5 {
+		t.Errorf("DFA has %d states, want 5:\n%v", n, dfa)
+	}
+	min := dfa.Minimize()
+	want := &DFA{
+		Alphabet: []Range{{'a', 'a'}, {'b', 'b'}},
+		Trans:    [][]int{{1, 0}, {1, 2}, {1, 3}, {1, 0}},
+		Accept:   []int{-1, -1, -1, 0},
+		Start:    0,
+	}
+	if !reflect.DeepEqual(min, want) {
+		t.Errorf("minimal DFA:\n%vwant:\n%v", min, want)
+	}
+	if again := min.Minimize(); !reflect.DeepEqual(again, min) {
+		t.Errorf("minimizing the minimal DFA gives:\n%v", again)
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	tests := []struct {
+		x, y string
+		want bool
+	}{
+		{`(a|b)*`, `(a*b*)*`, true},
+		{`(a|b)*abb`, `(a*b)*a(b|a)*abb|b*abb`, true},
+		{`(a|b)*abb`, `b*a(a|b*a)*bb`, true},
+		{`(a|b)*abb`, `(a|b)+abb`, false},
+		{`(a|b)*abb`, `(a|b)*ab`, false},
+		{`a+`, `a*`, false},
+		{`a+`, `aa*`, true},
+		{`a|b`, `b|a`, true},
+		{`ab`, `ba`, false},
+		{`a?`, `a|`, true},
+		{`[a-c]`, `a|b|c`, true},
+		{`[a-c]`, `a|c`, false},
+		{`\d\d*`, `[0-9]+`, true},
+	}
+	for _, test := range tests {
+		x, y := MustCompile(test.x).DFA(), MustCompile(test.y).DFA()
+		if got := Equivalent(x, y); got != test.want {
+			t.Errorf("Equivalent(%s, %s) = %v, want %v", test.x, test.y, got, test.want)
+		}
+		if got := Equivalent(y.Minimize(), x); got != test.want {
+			t.Errorf("Equivalent(minimal %s, %s) = %v, want %v", test.y, test.x, got, test.want)
+		}
+	}
+
+	// the same strings accepted as different patterns
+	x := Combine(MustCompile(`if`), MustCompile(`[a-z]+`)).DFA()
+	y := Combine(MustCompile(`[a-z]+`), MustCompile(`if`)).DFA()
+	if Equivalent(x, y) {
+		t.Errorf("Equivalent(if and [a-z]+, [a-z]+ and if) = true, want false")
+	}
+}
+
+// TestDFAMatch checks that the DFA of every one of the patterns, and its
+// minimal DFA, accept the same strings as its NFA, and as the same pattern,
+// and that minimizing leaves no two equivalent states.
+func TestDFAMatch(t *testing.T) {
+	for _, expr := range patterns {
+		nfa := MustCompile(expr)
+		dfa := nfa.DFA()
+		min := dfa.Minimize()
+		if !Equivalent(dfa, min) {
+			t.Errorf("%q: minimizing changed the language", expr)
+		}
+		if min.NumStates() > dfa.NumStates() {
+			t.Errorf("%q: minimal DFA has %d states, more than the %d of the DFA", expr, min.NumStates(), dfa.NumStates())
+		}
+		for s := range min.Trans {
+			for u := s + 1; u < len(min.Trans); u++ {
+				if Equivalent(&DFA{min.Alphabet, min.Trans, min.Accept, s}, &DFA{min.Alphabet, min.Trans, min.Accept, u}) {
+					t.Errorf("%q: states %d and %d of the minimal DFA are equivalent:\n%v", expr, s, u, min)
+				}
+			}
+		}
+		for _, s := range inputs(testAlphabet, 4) {
+			n, pattern := nfa.Longest(s)
+			if dn, dp := dfa.Longest(s); dn != n || dp != pattern {
+				t.Errorf("%q: DFA Longest(%q) = %d, %d, want %d, %d", expr, s, dn, dp, n, pattern)
+			}
+			if mn, mp := min.Longest(s); mn != n || mp != pattern {
+				t.Errorf("%q: minimal DFA Longest(%q) = %d, %d, want %d, %d", expr, s, mn, mp, n, pattern)
+			}
+			if dfa.Match(s) != nfa.Match(s) {
+				t.Errorf("%q: DFA Match(%q) = %v, want %v", expr, s, dfa.Match(s), nfa.Match(s))
+			}
+		}
+	}
+}
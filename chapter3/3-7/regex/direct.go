@@ -0,0 +1,156 @@
+package regex
+
+import "sort"
+
+// Direct returns a DFA for the syntax trees, built from them without an NFA
+// by Algorithm 3.36. Tree i is augmented with an endmarker #i, and the DFA
+// accepts pattern i in the states holding the position of #i, the smallest
+// such i when there are several, as NFA.DFA does.
+func Direct(trees ...*Node) *DFA {
+	f := &followpos{}
+	var first []int
+	for i, n := range trees {
+		// the positions of (n)#i, joined to the other trees by a union
+		nullable, fp, lp := f.visit(n)
+		end := f.position(nil, i)
+		for _, p := range lp {
+			f.follow[p] = union(f.follow[p], []int{end})
+		}
+		if nullable {
+			fp = union(fp, []int{end})
+		}
+		first = union(first, fp)
+	}
+
+	var classes []Class
+	for _, c := range f.class {
+		if c != nil {
+			classes = append(classes, c)
+		}
+	}
+	d := &DFA{Alphabet: splitRanges(classes)}
+	dstates := map[string]int{}
+	var unmarked [][]int
+	add := func(positions []int) int {
+		key := setKey(positions)
+		if s, ok := dstates[key]; ok {
+			return s
+		}
+		s := len(d.Trans)
+		dstates[key] = s
+		d.Trans = append(d.Trans, make([]int, len(d.Alphabet)))
+		accept := -1
+		for _, p := range positions {
+			if f.class[p] == nil && (accept < 0 || f.pattern[p] < accept) {
+				accept = f.pattern[p]
+			}
+		}
+		d.Accept = append(d.Accept, accept)
+		unmarked = append(unmarked, positions)
+		return s
+	}
+	d.Start = add(first)
+	for s := 0; s < len(unmarked); s++ {
+		for a, r := range d.Alphabet {
+			var u []int
+			for _, p := range unmarked[s] {
+				if f.class[p] != nil && f.class[p].Contains(r.Lo) {
+					u = union(u, f.follow[p])
+				}
+			}
+			if u == nil {
+				d.Trans[s][a] = -1
+			} else {
+				d.Trans[s][a] = add(u)
+			}
+		}
+	}
+	return d
+}
+
+// followpos holds the positions of the leaves of syntax trees, numbered from
+// 0 in the order they are visited, and the followpos of each.
+type followpos struct {
+	class   []Class // the characters at each position; nil for an endmarker
+	pattern []int   // the pattern of each endmarker
+	follow  [][]int
+}
+
+func (f *followpos) position(c Class, pattern int) int {
+	f.class = append(f.class, c)
+	f.pattern = append(f.pattern, pattern)
+	f.follow = append(f.follow, nil)
+	return len(f.class) - 1
+}
+
+// visit numbers the positions of n and computes nullable(n), firstpos(n)
+// and lastpos(n) by the rules of Fig. 3.58, adding to followpos by the two
+// rules of section 3.9.4 on the way.
+func (f *followpos) visit(n *Node) (nullable bool, first, last []int) {
+	switch n.Op {
+	case OpEmpty:
+		return true, nil, nil
+	case OpClass:
+		c := n.Class
+		if c == nil {
+			c = Class{}
+		}
+		p := f.position(c, -1)
+		return false, []int{p}, []int{p}
+	case OpUnion:
+		for _, sub := range n.Sub {
+			nb, fb, lb := f.visit(sub)
+			nullable = nullable || nb
+			first = union(first, fb)
+			last = union(last, lb)
+		}
+		return nullable, first, last
+	case OpCat:
+		nullable = true
+		for _, sub := range n.Sub {
+			nb, fb, lb := f.visit(sub)
+			// every position of lastpos(c1) is followed by firstpos(c2)
+			for _, p := range last {
+				f.follow[p] = union(f.follow[p], fb)
+			}
+			if nullable {
+				first = union(first, fb)
+			}
+			if nb {
+				last = union(last, lb)
+			} else {
+				last = lb
+			}
+			nullable = nullable && nb
+		}
+		return nullable, first, last
+	}
+	// OpStar, OpPlus and OpQuest
+	nullable, first, last = f.visit(n.Sub[0])
+	if n.Op != OpQuest {
+		// every position of lastpos(n) is followed by firstpos(n)
+		for _, p := range last {
+			f.follow[p] = union(f.follow[p], first)
+		}
+	}
+	return nullable || n.Op != OpPlus, first, last
+}
+
+// union returns the union of the sorted sets of positions a and b.
+func union(a, b []int) []int {
+	if len(b) == 0 {
+		return a
+	}
+	u := make([]int, 0, len(a)+len(b))
+	u = append(u, a...)
+	u = append(u, b...)
+	sort.Ints(u)
+	n := 0
+	for i, p := range u {
+		if i == 0 || p != u[n-1] {
+			u[n] = p
+			n++
+		}
+	}
+	return u[:n]
+}
@@ -0,0 +1,57 @@
+package regex
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFollowpos checks the positions of (a|b)*abb# against Fig. 3.61, where
+// they are numbered from 1 and here from 0.
+func TestFollowpos(t *testing.T) {
+	f := &followpos{}
+	nullable, first, last := f.visit(MustParse(`(a|b)*abb`))
+	end := f.position(nil, 0)
+	for _, p := range last {
+		f.follow[p] = union(f.follow[p], []int{end})
+	}
+	if nullable || !reflect.DeepEqual(first, []int{0, 1, 2}) || !reflect.DeepEqual(last, []int{4}) {
+		t.Errorf("nullable, firstpos, lastpos = %v, %v, %v, want false, [0 1 2], [4]", nullable, first, last)
+	}
+	want := [][]int{{0, 1, 2}, {0, 1, 2}, {3}, {4}, {5}, nil}
+	if !reflect.DeepEqual(f.follow, want) {
+		t.Errorf("followpos = %v, want %v", f.follow, want)
+	}
+	classes := []string{"a", "b", "a", "b", "b"}
+	for p, c := range classes {
+		if f.class[p].String() != c {
+			t.Errorf("position %d holds %v, want %s", p, f.class[p], c)
+		}
+	}
+	if f.class[end] != nil || f.pattern[end] != 0 {
+		t.Errorf("position %d holds %v of pattern %d, want the endmarker of pattern 0", end, f.class[end], f.pattern[end])
+	}
+
+	// Example 3.37 builds the DFA of Fig. 3.63, with four states.
+	if d := Direct(MustParse(`(a|b)*abb`)); d.NumStates() != 4 {
+		t.Errorf("Direct((a|b)*abb) has %d states, want 4:\n%v", d.NumStates(), d)
+	}
+}
+
+// TestDirect checks that the direct construction accepts the same strings
+// as the subset construction from the NFA, for every one of the patterns
+// alone and for all of them together, each as its own pattern.
+func TestDirect(t *testing.T) {
+	var trees []*Node
+	var nfas []*NFA
+	for _, expr := range patterns {
+		tree := MustParse(expr)
+		if !Equivalent(Direct(tree), Thompson(tree).DFA().Minimize()) {
+			t.Errorf("%q: the direct and the subset construction differ", expr)
+		}
+		trees = append(trees, tree)
+		nfas = append(nfas, Thompson(tree))
+	}
+	if !Equivalent(Direct(trees...), Combine(nfas...).DFA().Minimize()) {
+		t.Errorf("the direct and the subset construction differ for the patterns together")
+	}
+}
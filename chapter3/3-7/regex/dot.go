@@ -0,0 +1,113 @@
+package regex
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteDOT writes the NFA as a graph named name in the DOT language of
+// Graphviz: an arrow marked start points to the start state, and accepting
+// states have a double circle, labeled with their pattern when the NFA has
+// more than one.
+func (nfa *NFA) WriteDOT(w io.Writer, name string) error {
+	accept := make([]int, len(nfa.States))
+	var edges []dotEdge
+	for s, state := range nfa.States {
+		accept[s] = state.Accept
+		for _, e := range state.Edges {
+			edges = append(edges, dotEdge{s, e.To, e.Class})
+		}
+	}
+	return writeDOT(w, name, nfa.Start, accept, edges)
+}
+
+// WriteDOT writes the DFA as a graph named name in the DOT language of
+// Graphviz, like NFA.WriteDOT; the dead state is left out.
+func (d *DFA) WriteDOT(w io.Writer, name string) error {
+	var edges []dotEdge
+	for s, row := range d.Trans {
+		for a, t := range row {
+			if t >= 0 {
+				edges = append(edges, dotEdge{s, t, Class{d.Alphabet[a]}})
+			}
+		}
+	}
+	return writeDOT(w, name, d.Start, d.Accept, edges)
+}
+
+type dotEdge struct {
+	from, to int
+	class    Class // nil for ε
+}
+
+func writeDOT(w io.Writer, name string, start int, accept []int, edges []dotEdge) error {
+	patterns := map[int]bool{}
+	for _, p := range accept {
+		if p >= 0 {
+			patterns[p] = true
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", QuoteDOT(name))
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=circle];\n")
+	b.WriteString("\tstart [shape=plaintext];\n")
+	fmt.Fprintf(&b, "\tstart -> %d;\n", start)
+	for s, p := range accept {
+		if p < 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\t%d [shape=doublecircle", s)
+		if len(patterns) > 1 {
+			fmt.Fprintf(&b, ", xlabel=\"%d\"", p)
+		}
+		b.WriteString("];\n")
+	}
+
+	// one edge for each pair of states, labeled with all its characters
+	type pair struct{ from, to int }
+	var pairs []pair
+	labels := map[pair][]Class{}
+	eps := map[pair]bool{}
+	for _, e := range edges {
+		p := pair{e.from, e.to}
+		if _, ok := labels[p]; !ok && !eps[p] {
+			pairs = append(pairs, p)
+		}
+		if e.class == nil {
+			eps[p] = true
+		} else {
+			labels[p] = append(labels[p], e.class)
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].from != pairs[j].from {
+			return pairs[i].from < pairs[j].from
+		}
+		return pairs[i].to < pairs[j].to
+	})
+	for _, p := range pairs {
+		var label []string
+		if eps[p] {
+			label = append(label, "ε")
+		}
+		if classes := labels[p]; classes != nil {
+			var ranges []Range
+			for _, c := range classes {
+				ranges = append(ranges, c...)
+			}
+			label = append(label, newClass(ranges...).String())
+		}
+		fmt.Fprintf(&b, "\t%d -> %d [label=%s];\n", p.from, p.to, QuoteDOT(strings.Join(label, ", ")))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// QuoteDOT returns s as a string of the DOT language.
+func QuoteDOT(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
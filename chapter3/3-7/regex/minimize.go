@@ -0,0 +1,162 @@
+package regex
+
+// Minimize returns the DFA with the fewest states that accepts the same
+// strings as d, each as the same pattern. It refines the partition of the
+// states into one group for each pattern and one for the non-accepting states
+// as in section 3.9.6, choosing the groups to split by as Hopcroft's algorithm
+// does. The states of the result are numbered in breadth-first order from
+// its start state, so equivalent DFAs minimize to identical ones.
+func (d *DFA) Minimize() *DFA {
+	// d made total by a dead state, numbered n-1
+	n := len(d.Trans) + 1
+	dead := n - 1
+	next := func(s, a int) int {
+		if s == dead || d.Trans[s][a] < 0 {
+			return dead
+		}
+		return d.Trans[s][a]
+	}
+	inv := make([][][]int, len(d.Alphabet)) // inv[a][t] holds the states entering t on a
+	for a := range inv {
+		inv[a] = make([][]int, n)
+		for s := 0; s < n; s++ {
+			t := next(s, a)
+			inv[a][t] = append(inv[a][t], s)
+		}
+	}
+
+	block := make([]int, n) // the group of each state
+	var blocks [][]int
+	byPattern := map[int]int{}
+	for s := 0; s < n; s++ {
+		p := -1
+		if s != dead {
+			p = d.Accept[s]
+		}
+		b, ok := byPattern[p]
+		if !ok {
+			b = len(blocks)
+			byPattern[p] = b
+			blocks = append(blocks, nil)
+		}
+		block[s] = b
+		blocks[b] = append(blocks[b], s)
+	}
+
+	// work holds the groups still to split by; there are never more than n
+	var work []int
+	inWork := make([]bool, n)
+	for b := range blocks {
+		work = append(work, b)
+		inWork[b] = true
+	}
+	count := make([]int, n) // states of each group that enter the splitter
+	marked := make([]bool, n)
+	for len(work) > 0 {
+		splitter := append([]int(nil), blocks[work[len(work)-1]]...)
+		inWork[work[len(work)-1]] = false
+		work = work[:len(work)-1]
+		for a := range d.Alphabet {
+			var entering, touched []int
+			for _, t := range splitter {
+				for _, s := range inv[a][t] {
+					entering = append(entering, s)
+					marked[s] = true
+					if count[block[s]] == 0 {
+						touched = append(touched, block[s])
+					}
+					count[block[s]]++
+				}
+			}
+			for _, b := range touched {
+				if count[b] < len(blocks[b]) {
+					var in, out []int
+					for _, s := range blocks[b] {
+						if marked[s] {
+							in = append(in, s)
+						} else {
+							out = append(out, s)
+						}
+					}
+					nb := len(blocks)
+					blocks[b] = in
+					blocks = append(blocks, out)
+					for _, s := range out {
+						block[s] = nb
+					}
+					switch {
+					case inWork[b]:
+						work = append(work, nb)
+						inWork[nb] = true
+					case len(in) <= len(out):
+						work = append(work, b)
+						inWork[b] = true
+					default:
+						work = append(work, nb)
+						inWork[nb] = true
+					}
+				}
+				count[b] = 0
+			}
+			for _, s := range entering {
+				marked[s] = false
+			}
+		}
+	}
+
+	// one state for each group reachable from the start, but the dead one
+	m := &DFA{Alphabet: d.Alphabet}
+	number := map[int]int{block[dead]: -1}
+	queue := []int{block[d.Start]}
+	number[block[d.Start]] = 0
+	for i := 0; i < len(queue); i++ {
+		s := blocks[queue[i]][0]
+		row := make([]int, len(d.Alphabet))
+		for a := range row {
+			b := block[next(s, a)]
+			t, ok := number[b]
+			if !ok {
+				t = len(queue)
+				number[b] = t
+				queue = append(queue, b)
+			}
+			row[a] = t
+		}
+		p := -1 // s is dead when no string at all is accepted
+		if s != dead {
+			p = d.Accept[s]
+		}
+		m.Trans = append(m.Trans, row)
+		m.Accept = append(m.Accept, p)
+	}
+	return m
+}
+
+// Equivalent reports whether the DFAs x and y accept the same strings, each
+// as the same pattern. It walks the pairs of states that x and y reach on
+// the same input, looking for one in which they accept differently.
+func Equivalent(x, y *DFA) bool {
+	symbols := splitRanges([]Class{x.Alphabet, y.Alphabet})
+	type pair struct{ x, y int }
+	seen := map[pair]bool{{x.Start, y.Start}: true}
+	accept := func(d *DFA, s int) int {
+		if s < 0 {
+			return -1
+		}
+		return d.Accept[s]
+	}
+	for queue := []pair{{x.Start, y.Start}}; len(queue) > 0; queue = queue[1:] {
+		p := queue[0]
+		if accept(x, p.x) != accept(y, p.y) {
+			return false
+		}
+		for _, r := range symbols {
+			q := pair{x.step(p.x, r.Lo), y.step(p.y, r.Lo)}
+			if !seen[q] {
+				seen[q] = true
+				queue = append(queue, q)
+			}
+		}
+	}
+	return true
+}
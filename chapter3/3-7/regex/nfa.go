@@ -0,0 +1,254 @@
+package regex
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// NFA is a nondeterministic finite automaton. The NFA built from one
+// expression has a single accepting state with no edges out of it.
+type NFA struct {
+	States []State
+	Start  int
+}
+
+// State is a state of an NFA; Accept is the number of the pattern accepted
+// in it, or -1 if it is not accepting.
+type State struct {
+	Edges  []Edge
+	Accept int
+}
+
+// Edge is a transition to state To on any character of Class, or on ε if
+// Class is nil.
+type Edge struct {
+	Class Class
+	To    int
+}
+
+// Compile parses expr and returns its NFA, whose accepting state accepts
+// pattern 0.
+func Compile(expr string) (*NFA, error) {
+	n, err := Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return Thompson(n), nil
+}
+
+// MustCompile is like Compile but panics if expr can not be parsed; it is
+// meant for expressions written into a program.
+func MustCompile(expr string) *NFA {
+	nfa, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return nfa
+}
+
+// Thompson returns the NFA for the syntax tree n by the McNaughton-Yamada-
+// Thompson construction, Algorithm 3.23. Concatenation joins the accepting
+// state of its first operand to the start of the second by an ε-edge instead
+// of merging the two states, which changes neither the language nor the
+// properties of the construction.
+func Thompson(n *Node) *NFA {
+	b := &builder{}
+	start, accept := b.build(n)
+	b.states[accept].Accept = 0
+	return &NFA{b.states, start}
+}
+
+// Combine returns the NFA of Fig. 3.52, which joins a new start state by
+// ε-edges to the start of every one of nfas; the states that accept in
+// nfas[i] accept pattern i.
+func Combine(nfas ...*NFA) *NFA {
+	b := &builder{}
+	start := b.newState()
+	for i, nfa := range nfas {
+		offset := len(b.states)
+		for _, s := range nfa.States {
+			state := State{Accept: -1}
+			if s.Accept >= 0 {
+				state.Accept = i
+			}
+			for _, e := range s.Edges {
+				state.Edges = append(state.Edges, Edge{e.Class, e.To + offset})
+			}
+			b.states = append(b.states, state)
+		}
+		b.edge(start, nfa.Start+offset, nil)
+	}
+	return &NFA{b.states, start}
+}
+
+type builder struct {
+	states []State
+}
+
+func (b *builder) newState() int {
+	b.states = append(b.states, State{Accept: -1})
+	return len(b.states) - 1
+}
+
+func (b *builder) edge(from, to int, c Class) {
+	b.states[from].Edges = append(b.states[from].Edges, Edge{c, to})
+}
+
+// build adds the states of N(n) and returns its start and accepting states.
+func (b *builder) build(n *Node) (start, accept int) {
+	switch n.Op {
+	case OpEmpty:
+		start, accept = b.newState(), b.newState()
+		b.edge(start, accept, nil)
+	case OpClass:
+		c := n.Class
+		if c == nil {
+			c = Class{} // an empty class still is not ε
+		}
+		start, accept = b.newState(), b.newState()
+		b.edge(start, accept, c)
+	case OpCat:
+		start, accept = b.build(n.Sub[0])
+		for _, sub := range n.Sub[1:] {
+			s, f := b.build(sub)
+			b.edge(accept, s, nil)
+			accept = f
+		}
+	case OpUnion:
+		start, accept = b.newState(), b.newState()
+		for _, sub := range n.Sub {
+			s, f := b.build(sub)
+			b.edge(start, s, nil)
+			b.edge(f, accept, nil)
+		}
+	case OpStar, OpPlus, OpQuest:
+		start = b.newState()
+		s, f := b.build(n.Sub[0])
+		accept = b.newState()
+		b.edge(start, s, nil)
+		if n.Op != OpQuest {
+			b.edge(f, s, nil)
+		}
+		b.edge(f, accept, nil)
+		if n.Op != OpPlus {
+			b.edge(start, accept, nil)
+		}
+	}
+	return start, accept
+}
+
+// stateSet is a set of NFA states, kept both as a list and, to test
+// membership in constant time, as the alreadyOn array of section 3.7.3.
+type stateSet struct {
+	states    []int
+	alreadyOn []bool
+}
+
+func newStateSet(n int) *stateSet {
+	return &stateSet{alreadyOn: make([]bool, n)}
+}
+
+func (set *stateSet) clear() {
+	for _, s := range set.states {
+		set.alreadyOn[s] = false
+	}
+	set.states = set.states[:0]
+}
+
+// addClosure adds s and every state reachable from it along ε-edges to set.
+func (nfa *NFA) addClosure(set *stateSet, s int) {
+	if set.alreadyOn[s] {
+		return
+	}
+	set.alreadyOn[s] = true
+	set.states = append(set.states, s)
+	for stack := []int{s}; len(stack) > 0; {
+		t := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, e := range nfa.States[t].Edges {
+			if e.Class == nil && !set.alreadyOn[e.To] {
+				set.alreadyOn[e.To] = true
+				set.states = append(set.states, e.To)
+				stack = append(stack, e.To)
+			}
+		}
+	}
+}
+
+// step sets next to ε-closure(move(cur, r)).
+func (nfa *NFA) step(cur, next *stateSet, r rune) {
+	next.clear()
+	for _, s := range cur.states {
+		for _, e := range nfa.States[s].Edges {
+			if e.Class != nil && e.Class.Contains(r) {
+				nfa.addClosure(next, e.To)
+			}
+		}
+	}
+}
+
+// accepted returns the smallest pattern accepted by a state of set, or -1.
+func (nfa *NFA) accepted(set *stateSet) int {
+	pattern := -1
+	for _, s := range set.states {
+		if a := nfa.States[s].Accept; a >= 0 && (pattern < 0 || a < pattern) {
+			pattern = a
+		}
+	}
+	return pattern
+}
+
+// Match reports whether the NFA accepts the whole of s, simulating it as in
+// Algorithm 3.22.
+func (nfa *NFA) Match(s string) bool {
+	n, _ := nfa.Longest(s)
+	return n == len(s)
+}
+
+// Longest returns the length in bytes of the longest prefix of s that the NFA
+// accepts, together with the pattern accepted there; when two patterns accept
+// the same prefix, the one with the smaller number wins. If no prefix of s is
+// accepted, Longest returns -1, -1.
+func (nfa *NFA) Longest(s string) (n, pattern int) {
+	cur, next := newStateSet(len(nfa.States)), newStateSet(len(nfa.States))
+	nfa.addClosure(cur, nfa.Start)
+	n, pattern = -1, -1
+	for i := 0; ; {
+		if p := nfa.accepted(cur); p >= 0 {
+			n, pattern = i, p
+		}
+		if i == len(s) || len(cur.states) == 0 {
+			return n, pattern
+		}
+		r, w := utf8.DecodeRuneInString(s[i:])
+		nfa.step(cur, next, r)
+		cur, next = next, cur
+		i += w
+	}
+}
+
+// String lists the states of the NFA and their edges, one state a line.
+func (nfa *NFA) String() string {
+	var b strings.Builder
+	for i, s := range nfa.States {
+		mark := ' '
+		if i == nfa.Start {
+			mark = '>'
+		}
+		fmt.Fprintf(&b, "%c%d", mark, i)
+		if s.Accept >= 0 {
+			fmt.Fprintf(&b, " accept %d", s.Accept)
+		}
+		b.WriteByte(':')
+		for _, e := range s.Edges {
+			if e.Class == nil {
+				fmt.Fprintf(&b, " ε->%d", e.To)
+			} else {
+				fmt.Fprintf(&b, " %v->%d", e.Class, e.To)
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
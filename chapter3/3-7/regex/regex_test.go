@@ -0,0 +1,212 @@
+package regex
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// patterns are written in the syntax that Parse shares with package regexp,
+// so that their automata can be checked against it.
+var patterns = []string{
+	``,
+	`a`,
+	`ab`,
+	`a|b`,
+	`a|`,
+	`(a|b)*abb`,
+	`(a|b)*`,
+	`(a*b*)*`,
+	`a+`,
+	`a*`,
+	`a?b`,
+	`a*b+_?`,
+	`(ab|a)(b|)`,
+	`((a))+`,
+	`(a|b|1)?(_|λ)`,
+	`a(b|1)*_`,
+	`[ab]+`,
+	`[^a]*`,
+	`[a-c1]*`,
+	`[]a]+`,
+	`[^]a]`,
+	`[a-]`,
+	`[\d_]+`,
+	`.*`,
+	`a.b`,
+	`\d+`,
+	`\w*`,
+	`\s`,
+	`\D\W`,
+	`\pL+`,
+	`\p{Greek}`,
+	`\PL`,
+	`\x61b`,
+	`\x{3bb}`,
+	`\.|\*|\+`,
+	`\n|\t`,
+}
+
+// inputs returns every string of at most n characters of alphabet.
+func inputs(alphabet string, n int) []string {
+	all := []string{""}
+	for last := all; n > 0; n-- {
+		var next []string
+		for _, s := range last {
+			for _, r := range alphabet {
+				next = append(next, s+string(r))
+			}
+		}
+		all = append(all, next...)
+		last = next
+	}
+	return all
+}
+
+// testAlphabet holds a character of every class of the patterns.
+const testAlphabet = "ab1_ \nλ."
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{`a)`, `regex: "a)" at offset 1: unmatched )`},
+		{`(a`, `regex: "(a" at offset 0: missing )`},
+		{`(a|(b)`, `regex: "(a|(b)" at offset 0: missing )`},
+		{`*a`, `regex: "*a" at offset 0: missing operand of *`},
+		{`a|+`, `regex: "a|+" at offset 2: missing operand of +`},
+		{`(?a)`, `regex: "(?a)" at offset 1: missing operand of ?`},
+		{`a{2}`, `regex: "a{2}" at offset 1: unescaped {`},
+		{`a}`, `regex: "a}" at offset 1: unescaped }`},
+		{`^a`, `regex: "^a" at offset 0: unescaped ^`},
+		{`a$`, `regex: "a$" at offset 1: unescaped $`},
+		{`a]`, `regex: "a]" at offset 1: unescaped ]`},
+		{`[ab`, `regex: "[ab" at offset 0: missing ]`},
+		{`x[]`, `regex: "x[]" at offset 1: missing ]`},
+		{`[b-a]`, `regex: "[b-a]" at offset 4: invalid range b-a`},
+		{`[c-a-]`, `regex: "[c-a-]" at offset 4: invalid range c-a`},
+		{`[a-\d]`, `regex: "[a-\\d]" at offset 5: invalid range end`},
+		{`\q`, `regex: "\\q" at offset 0: invalid escape sequence`},
+		{`a\`, `regex: "a\\" at offset 1: invalid escape sequence`},
+		{`\xZZ`, `regex: "\\xZZ" at offset 0: invalid escape sequence`},
+		{`\x6`, `regex: "\\x6" at offset 0: invalid escape sequence`},
+		{`\x{61`, `regex: "\\x{61" at offset 0: missing } in escape sequence`},
+		{`\x{110000}`, `regex: "\\x{110000}" at offset 0: invalid escape sequence`},
+		{`\p{Foo}`, `regex: "\\p{Foo}" at offset 0: unknown Unicode class Foo`},
+		{`\p{L`, `regex: "\\p{L" at offset 0: missing } in Unicode class`},
+	}
+	for _, test := range tests {
+		n, err := Parse(test.expr)
+		if err == nil {
+			t.Errorf("Parse(%q) = %v, want error %s", test.expr, n, test.err)
+		} else if err.Error() != test.err {
+			t.Errorf("Parse(%q) error = %s, want %s", test.expr, err, test.err)
+		}
+	}
+}
+
+// dump writes out the tree n with every operator and its operands.
+func dump(n *Node) string {
+	var ops = [...]string{OpCat: "cat", OpUnion: "union", OpStar: "star", OpPlus: "plus", OpQuest: "quest"}
+	switch n.Op {
+	case OpEmpty:
+		return "ε"
+	case OpClass:
+		return n.Class.String()
+	}
+	subs := make([]string, len(n.Sub))
+	for i, sub := range n.Sub {
+		subs[i] = dump(sub)
+	}
+	return ops[n.Op] + "(" + strings.Join(subs, ",") + ")"
+}
+
+// TestParse checks the trees that Parse builds, which follow the precedence
+// of the operators, and that String writes them back as they are parsed.
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr, tree, str string
+	}{
+		{``, `ε`, `()`},
+		{`ab|c`, `union(cat(a,b),c)`, `ab|c`},
+		{`a|bc*`, `union(a,cat(b,star(c)))`, `a|bc*`},
+		{`(a|b)c`, `cat(union(a,b),c)`, `(a|b)c`},
+		{`ab*`, `cat(a,star(b))`, `ab*`},
+		{`(ab)*`, `star(cat(a,b))`, `(ab)*`},
+		{`a*?+`, `plus(quest(star(a)))`, `a*?+`},
+		{`a||b`, `union(a,ε,b)`, `a||b`},
+		{`(|a)b`, `cat(union(ε,a),b)`, `(|a)b`},
+		{`((a))|(b)c`, `union(a,cat(b,c))`, `a|bc`},
+		{`[a-c]x`, `cat([a-c],x)`, `[a-c]x`},
+		{`[^\x00-\x{10fffe}]`, `\x{10ffff}`, `\x{10ffff}`},
+		{`\.\[`, `cat(\.,\[)`, `\.\[`},
+		{`[\]\-^]`, `[\-\]-\^]`, `[\-\]-\^]`},
+	}
+	for _, test := range tests {
+		n, err := Parse(test.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", test.expr, err)
+			continue
+		}
+		if got := dump(n); got != test.tree {
+			t.Errorf("Parse(%q) = %s, want %s", test.expr, got, test.tree)
+		}
+		if got := n.String(); got != test.str {
+			t.Errorf("Parse(%q).String() = %s, want %s", test.expr, got, test.str)
+		}
+		if again := MustParse(n.String()); dump(again) != dump(n) {
+			t.Errorf("Parse(%q) = %s, but %s parses as %s", test.expr, dump(n), n, dump(again))
+		}
+	}
+}
+
+// TestNFAMatch checks that the NFA of every one of the patterns accepts the
+// same strings as package regexp does, and that Longest agrees with the
+// longest match of regexp.
+func TestNFAMatch(t *testing.T) {
+	for _, expr := range patterns {
+		nfa, err := Compile(expr)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", expr, err)
+			continue
+		}
+		whole := regexp.MustCompile(`^(?:` + expr + `)$`)
+		prefix := regexp.MustCompile(`^(?:` + expr + `)`)
+		prefix.Longest()
+		for _, s := range inputs(testAlphabet, 4) {
+			if got, want := nfa.Match(s), whole.MatchString(s); got != want {
+				t.Errorf("%q: NFA Match(%q) = %v, want %v", expr, s, got, want)
+			}
+			want := -1
+			if loc := prefix.FindStringIndex(s); loc != nil {
+				want = loc[1]
+			}
+			if got, _ := nfa.Longest(s); got != want {
+				t.Errorf("%q: NFA Longest(%q) = %d, want %d", expr, s, got, want)
+			}
+		}
+	}
+}
+
+// TestCombine checks that the NFA of Fig. 3.52 accepts a string as the
+// earliest of the patterns that accept it.
+func TestCombine(t *testing.T) {
+	nfa := Combine(MustCompile(`if`), MustCompile(`[a-z]+`), MustCompile(`[a-z]+\d`))
+	tests := []struct {
+		s          string
+		n, pattern int
+	}{
+		{"if", 2, 0},
+		{"iff", 3, 1},
+		{"if1", 3, 2},
+		{"i", 1, 1},
+		{"1", -1, -1},
+		{"ab1c", 3, 2},
+	}
+	for _, test := range tests {
+		if n, pattern := nfa.Longest(test.s); n != test.n || pattern != test.pattern {
+			t.Errorf("Longest(%q) = %d, %d, want %d, %d", test.s, n, pattern, test.n, test.pattern)
+		}
+	}
+}
@@ -0,0 +1,438 @@
+// Package regex turns regular expressions into finite automata as in section
+// 3.7: Parse builds the syntax tree of an expression, and Compile turns it
+// into an NFA by the McNaughton-Yamada-Thompson construction (Algorithm 3.23),
+// which can be simulated directly (Algorithm 3.22).
+//
+// The syntax is that of section 3.3.3 with its usual extensions:
+//
+//	r|s        union
+//	rs         concatenation
+//	r* r+ r?   zero or more, one or more, zero or one r
+//	(r)        grouping
+//	[abc] [a-z] [^a-z]  character classes, negated with ^
+//	.          any character but \n
+//	\n \t \r \f \v \0, \xhh, \x{h...}, \uhhhh  escaped characters
+//	\d \w \s, \D \W \S  digits, word characters, white space and their negations
+//	\pL \p{Greek}, \PL \P{Greek}  Unicode categories and scripts, negated with \P
+//	\c         the character c itself, for any punctuation c
+//
+// The characters { } ^ $ and ] have no meaning of their own outside a
+// character class, but must be escaped there all the same.
+//
+// The operators bind from tightest to loosest in the order *+?,
+// concatenation, |, and an empty expression or alternative denotes ε.
+package regex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// metachars are the characters that must be escaped to stand for themselves
+// outside a character class.
+const metachars = `\.+*?()|[]{}^$`
+
+// Op is the operator at a Node of a syntax tree.
+type Op int
+
+const (
+	OpEmpty Op = iota // ε, the empty string
+	OpClass           // any one character of Class
+	OpCat             // Sub[0] followed by Sub[1] and so on
+	OpUnion           // any one of Sub
+	OpStar            // zero or more Sub[0]
+	OpPlus            // one or more Sub[0]
+	OpQuest           // zero or one Sub[0]
+)
+
+// Node is a node of the syntax tree of a regular expression.
+type Node struct {
+	Op    Op
+	Class Class // for OpClass
+	Sub   []*Node
+}
+
+// String returns n in the syntax of Parse, with as few parentheses as the
+// precedence of the operators allows.
+func (n *Node) String() string {
+	var b strings.Builder
+	n.write(&b)
+	return b.String()
+}
+
+func (n *Node) write(b *strings.Builder) {
+	switch n.Op {
+	case OpEmpty:
+		b.WriteString("()")
+	case OpClass:
+		b.WriteString(n.Class.String())
+	case OpCat:
+		for _, sub := range n.Sub {
+			sub.writeIn(b, OpCat)
+		}
+	case OpUnion:
+		for i, sub := range n.Sub {
+			if i > 0 {
+				b.WriteByte('|')
+			}
+			if sub.Op != OpEmpty {
+				sub.writeIn(b, OpUnion)
+			}
+		}
+	case OpStar, OpPlus, OpQuest:
+		n.Sub[0].writeIn(b, OpStar)
+		b.WriteByte("*+?"[n.Op-OpStar])
+	}
+}
+
+// writeIn writes n as an operand of an operator op, in parentheses if n binds
+// more loosely than op.
+func (n *Node) writeIn(b *strings.Builder, op Op) {
+	if precedence(n.Op) < precedence(op) {
+		b.WriteByte('(')
+		n.write(b)
+		b.WriteByte(')')
+	} else {
+		n.write(b)
+	}
+}
+
+func precedence(op Op) int {
+	switch op {
+	case OpUnion:
+		return 1
+	case OpCat:
+		return 2
+	}
+	return 3
+}
+
+// SyntaxError reports a malformed regular expression; Pos is the byte offset
+// in Expr at which the problem was found.
+type SyntaxError struct {
+	Expr string
+	Pos  int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("regex: %q at offset %d: %s", e.Expr, e.Pos, e.Msg)
+}
+
+// Parse returns the syntax tree of the regular expression expr.
+func Parse(expr string) (*Node, error) {
+	p := &parser{expr: expr}
+	n, err := p.union()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.expr) {
+		// only an unmatched ) stops union before the end
+		return nil, p.errorf("unmatched )")
+	}
+	return n, nil
+}
+
+// MustParse is like Parse but panics if expr can not be parsed.
+func MustParse(expr string) *Node {
+	n, err := Parse(expr)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// parser is a recursive-descent parser for the grammar
+//
+//	union  -> concat ( '|' concat )*
+//	concat -> repeat*
+//	repeat -> atom ( '*' | '+' | '?' )*
+//	atom   -> '(' union ')' | '[' class ']' | '.' | '\' escape | char
+type parser struct {
+	expr string
+	pos  int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{p.expr, p.pos, fmt.Sprintf(format, args...)}
+}
+
+// peek returns the next character of the expression and its width, or -1 at
+// the end of the expression.
+func (p *parser) peek() (rune, int) {
+	if p.pos >= len(p.expr) {
+		return -1, 0
+	}
+	return utf8.DecodeRuneInString(p.expr[p.pos:])
+}
+
+func (p *parser) next() rune {
+	r, w := p.peek()
+	p.pos += w
+	return r
+}
+
+// lookingAt reports whether the next character of the expression is r.
+func (p *parser) lookingAt(r rune) bool {
+	next, _ := p.peek()
+	return next == r
+}
+
+func (p *parser) union() (*Node, error) {
+	n, err := p.concat()
+	if err != nil {
+		return nil, err
+	}
+	if !p.lookingAt('|') {
+		return n, nil
+	}
+	u := &Node{Op: OpUnion, Sub: []*Node{n}}
+	for p.lookingAt('|') {
+		p.next()
+		n, err := p.concat()
+		if err != nil {
+			return nil, err
+		}
+		u.Sub = append(u.Sub, n)
+	}
+	return u, nil
+}
+
+func (p *parser) concat() (*Node, error) {
+	var subs []*Node
+	for !p.lookingAt(-1) && !p.lookingAt('|') && !p.lookingAt(')') {
+		n, err := p.repeat()
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, n)
+	}
+	switch len(subs) {
+	case 0:
+		return &Node{Op: OpEmpty}, nil
+	case 1:
+		return subs[0], nil
+	}
+	return &Node{Op: OpCat, Sub: subs}, nil
+}
+
+func (p *parser) repeat() (*Node, error) {
+	n, err := p.atom()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		r, _ := p.peek()
+		switch r {
+		case '*':
+			n = &Node{Op: OpStar, Sub: []*Node{n}}
+		case '+':
+			n = &Node{Op: OpPlus, Sub: []*Node{n}}
+		case '?':
+			n = &Node{Op: OpQuest, Sub: []*Node{n}}
+		default:
+			return n, nil
+		}
+		p.next()
+	}
+}
+
+func (p *parser) atom() (*Node, error) {
+	start := p.pos
+	switch r := p.next(); r {
+	case '(':
+		n, err := p.union()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ')' {
+			p.pos = start
+			return nil, p.errorf("missing )")
+		}
+		return n, nil
+	case '[':
+		c, err := p.class()
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Op: OpClass, Class: c}, nil
+	case '.':
+		return &Node{Op: OpClass, Class: newClass(Range{'\n', '\n'}).negate()}, nil
+	case '\\':
+		c, err := p.escape()
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Op: OpClass, Class: c}, nil
+	case '*', '+', '?':
+		p.pos = start
+		return nil, p.errorf("missing operand of %c", r)
+	case '{', '}', '^', '$', ']':
+		p.pos = start
+		return nil, p.errorf("unescaped %c", r)
+	default:
+		return &Node{Op: OpClass, Class: newClass(Range{r, r})}, nil
+	}
+}
+
+// class parses a bracketed character class whose [ has just been read.
+func (p *parser) class() (Class, error) {
+	start := p.pos - 1
+	negated := false
+	if p.lookingAt('^') {
+		p.next()
+		negated = true
+	}
+	var ranges []Range
+	for first := true; ; first = false {
+		r := p.next()
+		switch {
+		case r < 0:
+			p.pos = start
+			return nil, p.errorf("missing ]")
+		case r == ']' && !first:
+			c := newClass(ranges...)
+			if negated {
+				c = c.negate()
+			}
+			return c, nil
+		case r == '\\':
+			c, err := p.escape()
+			if err != nil {
+				return nil, err
+			}
+			if len(c) != 1 || c[0].Lo != c[0].Hi {
+				// \d and the like can not bound a range
+				ranges = append(ranges, c...)
+				continue
+			}
+			r = c[0].Lo
+		}
+		lo := r
+		if p.lookingAt('-') && !strings.HasPrefix(p.expr[p.pos:], "-]") {
+			p.next()
+			hi := p.next()
+			if hi == '\\' {
+				c, err := p.escape()
+				if err != nil {
+					return nil, err
+				}
+				if len(c) != 1 || c[0].Lo != c[0].Hi {
+					return nil, p.errorf("invalid range end")
+				}
+				hi = c[0].Lo
+			}
+			if hi < lo {
+				return nil, p.errorf("invalid range %c-%c", lo, hi)
+			}
+			ranges = append(ranges, Range{lo, hi})
+			continue
+		}
+		ranges = append(ranges, Range{lo, lo})
+	}
+}
+
+// escape parses an escape sequence whose \ has just been read.
+func (p *parser) escape() (Class, error) {
+	start := p.pos - 1
+	r := p.next()
+	switch r {
+	case 'n':
+		return newClass(Range{'\n', '\n'}), nil
+	case 't':
+		return newClass(Range{'\t', '\t'}), nil
+	case 'r':
+		return newClass(Range{'\r', '\r'}), nil
+	case 'f':
+		return newClass(Range{'\f', '\f'}), nil
+	case 'v':
+		return newClass(Range{'\v', '\v'}), nil
+	case '0':
+		return newClass(Range{0, 0}), nil
+	case 'x', 'u':
+		return p.hexEscape(r, start)
+	case 'd', 'D':
+		return perl(r, newClass(Range{'0', '9'})), nil
+	case 'w', 'W':
+		return perl(r, newClass(Range{'0', '9'}, Range{'A', 'Z'}, Range{'_', '_'}, Range{'a', 'z'})), nil
+	case 's', 'S':
+		return perl(r, newClass(Range{'\t', '\n'}, Range{'\f', '\r'}, Range{' ', ' '})), nil
+	case 'p', 'P':
+		return p.unicodeClass(r, start)
+	}
+	if r < 0 || r > unicode.MaxASCII || !unicode.IsPunct(r) && !unicode.IsSymbol(r) && r != ' ' {
+		p.pos = start
+		return nil, p.errorf("invalid escape sequence")
+	}
+	return newClass(Range{r, r}), nil
+}
+
+// perl returns c for the lower-case escapes \d, \w and \s and its negation for
+// the upper-case ones.
+func perl(r rune, c Class) Class {
+	if unicode.IsUpper(r) {
+		return c.negate()
+	}
+	return c
+}
+
+// hexEscape parses \xhh, \x{h...} or \uhhhh after its x or u.
+func (p *parser) hexEscape(kind rune, start int) (Class, error) {
+	n := 2
+	if kind == 'u' {
+		n = 4
+	}
+	var digits string
+	if kind == 'x' && p.lookingAt('{') {
+		end := strings.IndexByte(p.expr[p.pos:], '}')
+		if end < 0 {
+			p.pos = start
+			return nil, p.errorf("missing } in escape sequence")
+		}
+		digits = p.expr[p.pos+1 : p.pos+end]
+		p.pos += end + 1
+	} else {
+		if len(p.expr)-p.pos < n {
+			p.pos = start
+			return nil, p.errorf("invalid escape sequence")
+		}
+		digits = p.expr[p.pos : p.pos+n]
+		p.pos += n
+	}
+	v, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(v)) {
+		p.pos = start
+		return nil, p.errorf("invalid escape sequence")
+	}
+	return newClass(Range{rune(v), rune(v)}), nil
+}
+
+// unicodeClass parses \pX, \p{Name} or their \P negations after the p or P.
+func (p *parser) unicodeClass(kind rune, start int) (Class, error) {
+	name := string(p.next())
+	if name == "{" {
+		end := strings.IndexByte(p.expr[p.pos:], '}')
+		if end < 0 {
+			p.pos = start
+			return nil, p.errorf("missing } in Unicode class")
+		}
+		name = p.expr[p.pos : p.pos+end]
+		p.pos += end + 1
+	}
+	t := unicode.Categories[name]
+	if t == nil {
+		t = unicode.Scripts[name]
+	}
+	if t == nil {
+		p.pos = start
+		return nil, p.errorf("unknown Unicode class %s", name)
+	}
+	c := tableClass(t)
+	if kind == 'P' {
+		c = c.negate()
+	}
+	return c, nil
+}